@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	log "github.com/Sirupsen/logrus"
+	"github.com/italolelis/hellowork/model"
+	"github.com/italolelis/hellowork/repo"
+)
+
+// Back ends the status you are on right now, for when you come back early,
+// and lets the channels that were told about the absence know
+type Back struct {
+	repo     repo.Repository
+	notifier Notifier
+}
+
+func NewBack(repo repo.Repository, notifier Notifier) *Back {
+	return &Back{repo, notifier}
+}
+
+func (c *Back) Route() string {
+	return "back"
+}
+
+func (c *Back) Name() string {
+	return "Back"
+}
+
+func (c *Back) Description() string {
+	return "Ends your current status"
+}
+
+func (c *Back) Handle(conv *Conversation) {
+	ctx, cancel := newContext()
+	defer cancel()
+
+	id := conv.Message().UserID
+	var ended *model.Status
+	err := repo.Update(ctx, c.repo, id, func(user *model.User) error {
+		now := user.Now()
+		ended = user.StatusAt(now)
+		if nil == ended {
+			return model.ErrStatusNotFound
+		}
+
+		return user.ReturnEarly(ended.ID, now, model.UserID(id))
+	})
+
+	switch {
+	case err == repo.ErrNotFound || err == model.ErrStatusNotFound:
+		conv.Say("not_out")
+	case nil != err:
+		conv.SayError(err)
+	default:
+		conv.Say("welcome_back", ended.Reason)
+		c.notify(conv, ended)
+	}
+}
+
+// notify tells the channels the status was announced in, but the one the
+// user is already talking in
+func (c *Back) notify(conv *Conversation, ended *model.Status) {
+	text := conv.Catalogue.T("user_back", conv.Message().UserID)
+	for _, channel := range ended.Channels {
+		if channel == conv.Message().Channel {
+			continue
+		}
+
+		if err := c.notifier.Notify(channel, text); nil != err {
+			log.WithError(err).WithField("channel", channel).Warn("Could not tell the channel about the early return")
+		}
+	}
+}
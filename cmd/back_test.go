@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/italolelis/hellowork/i18n"
+	"github.com/italolelis/hellowork/model"
+	"github.com/italolelis/hellowork/repo"
+)
+
+func TestBack(t *testing.T) {
+	en := i18n.Get(i18n.English)
+	cases := []struct {
+		name    string
+		user    string
+		channel string
+		reply   string
+		sent    []string
+	}{
+		{"in a direct message", "U1", "D1", en.T("welcome_back", model.Vacation), []string{"C1: " + en.T("user_back", "U1"), "C2: " + en.T("user_back", "U1")}},
+		{"in an announced channel", "U1", "C1", en.T("welcome_back", model.Vacation), []string{"C2: " + en.T("user_back", "U1")}},
+		{"not out", "U2", "D1", en.T("not_out"), nil},
+		{"unknown", "U9", "D1", en.T("not_out"), nil},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctx := context.Background()
+			now := time.Now()
+			r := repo.NewInMemory()
+
+			// U1 is out until the day after tomorrow, U2 only next month
+			out := model.NewUser("U1")
+			vacation := model.NewStatus("", now.AddDate(0, 0, -1), now.AddDate(0, 0, 2), model.Vacation)
+			vacation.Channels = []string{"C1", "C2"}
+			out.AddStatus(vacation, "U1")
+			later := model.NewUser("U2")
+			later.AddStatus(model.NewStatus("", now.AddDate(0, 1, 0), now.AddDate(0, 1, 2), model.Vacation), "U2")
+			for _, user := range []*model.User{out, later} {
+				if err := r.Add(ctx, user); nil != err {
+					t.Fatal(err)
+				}
+			}
+
+			notifier := &fakeNotifier{}
+			conv, fake := newTestConversation(t, i18n.English, "back", c.user, c.channel, "I'm back")
+			NewBack(r, notifier).Handle(conv)
+
+			if want := []string{c.reply}; !reflect.DeepEqual(fake.replies, want) {
+				t.Errorf("replied %q, want %q", fake.replies, want)
+			}
+
+			if !reflect.DeepEqual(notifier.sent, c.sent) {
+				t.Errorf("sent %q, want %q", notifier.sent, c.sent)
+			}
+
+			user, err := r.Find(ctx, "U1")
+			if nil != err {
+				t.Fatal(err)
+			}
+
+			ended := user.FindStatus(vacation.ID).To.Before(time.Now().Add(time.Second))
+			if ended != (c.user == "U1") {
+				t.Errorf("the vacation ended is %t", ended)
+			}
+		})
+	}
+}
@@ -0,0 +1,232 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/italolelis/hellowork/i18n"
+	"github.com/italolelis/hellowork/model"
+	"github.com/italolelis/hellowork/repo"
+	htime "github.com/italolelis/hellowork/time"
+	"github.com/nlopes/slack"
+)
+
+// calendarNameWidth caps the column of the names, so the grid stays narrow
+// enough for a phone
+const calendarNameWidth = 16
+
+// Calendar shows who is out in a week or a month as a Monday to Friday grid
+// per person, as in "who is out next week" or "who is out in March"
+type Calendar struct {
+	client *slack.Client
+	repo   repo.Repository
+}
+
+func NewCalendar(client *slack.Client, repo repo.Repository) *Calendar {
+	return &Calendar{client, repo}
+}
+
+func (c *Calendar) Route() string {
+	return "calendar"
+}
+
+func (c *Calendar) Name() string {
+	return "Calendar"
+}
+
+func (c *Calendar) Description() string {
+	return "Shows who is out in a week or a month"
+}
+
+func (c *Calendar) Handle(conv *Conversation) {
+	loc := time.Local
+	if slackUser, err := c.client.GetUserInfo(conv.Message().UserID); nil == err {
+		loc = (&model.User{Location: slackUser.TZ}).TimeLocation()
+	} else {
+		log.Error(err)
+	}
+
+	from, to, ok := calendarPeriod(conv, time.Now().In(loc))
+	if !ok {
+		conv.Say("calendar_invalid_period")
+		return
+	}
+
+	ctx, cancel := newContext()
+	defer cancel()
+
+	users, err := c.repo.FindAllOutBetween(ctx, from, to)
+	if nil != err {
+		conv.SayError(err)
+		return
+	}
+
+	// people only out over the weekend don't show up in the grid
+	calendar := renderCalendar(conv.Catalogue, users, from, to)
+	if len(calendar) == 0 {
+		conv.Say("calendar_nobody", conv.Catalogue.Date(from), conv.Catalogue.Date(to))
+		return
+	}
+
+	conv.Reply(calendar + conv.Catalogue.T("calendar_legend"))
+}
+
+// calendarPeriod reads the period asked about from the route, which either
+// has a week, "this week" or "next week", or a month
+func calendarPeriod(conv *Conversation, now time.Time) (time.Time, time.Time, bool) {
+	if month, err := conv.Param("month"); nil == err {
+		m, ok := parseMonth(conv.Catalogue.Normalize(month))
+		if !ok {
+			return time.Time{}, time.Time{}, false
+		}
+
+		// a month already over this year is the one of next year
+		year := now.Year()
+		if m < now.Month() {
+			year++
+		}
+
+		from := time.Date(year, m, 1, 0, 0, 0, 0, now.Location())
+		return from, htime.EndOfDay(from.AddDate(0, 1, -1)), true
+	}
+
+	week, err := conv.Param("week")
+	if nil != err {
+		return time.Time{}, time.Time{}, false
+	}
+
+	monday := startOfWorkWeek(now)
+	switch strings.ToLower(conv.Catalogue.Normalize(week)) {
+	case "this week":
+	case "next week":
+		monday = monday.AddDate(0, 0, 7)
+	default:
+		return time.Time{}, time.Time{}, false
+	}
+
+	return monday, htime.EndOfDay(monday.AddDate(0, 0, 4)), true
+}
+
+// parseMonth reads an english month name, in full or abbreviated
+func parseMonth(text string) (time.Month, bool) {
+	text = strings.Title(strings.ToLower(strings.Trim(text, ".?! ")))
+	for _, layout := range []string{"January", "Jan"} {
+		if t, err := time.Parse(layout, text); nil == err {
+			return t.Month(), true
+		}
+	}
+
+	return 0, false
+}
+
+// startOfWorkWeek returns the start of the Monday of the date's week
+func startOfWorkWeek(date time.Time) time.Time {
+	offset := (int(date.Weekday()) + 6) % 7
+	return htime.StartOfDay(date.AddDate(0, 0, -offset))
+}
+
+// renderCalendar draws the weeks between from and to that somebody is out
+// in, or returns an empty string when there are none
+func renderCalendar(catalogue *i18n.Catalogue, users []*model.User, from time.Time, to time.Time) string {
+	var weeks []string
+	for monday := startOfWorkWeek(from); !monday.After(to); monday = monday.AddDate(0, 0, 7) {
+		if week := renderWeek(catalogue, users, monday, from, to); len(week) > 0 {
+			weeks = append(weeks, week)
+		}
+	}
+
+	return strings.Join(weeks, "\n")
+}
+
+// renderWeek draws the week starting on monday for the users out in it, in
+// a code block so the columns line up. Days outside of from and to are left
+// blank. It returns an empty string when nobody is out that week
+func renderWeek(catalogue *i18n.Catalogue, users []*model.User, monday time.Time, from time.Time, to time.Time) string {
+	var rows []string
+	for _, user := range users {
+		loc := user.TimeLocation()
+		cells := make([]string, 5)
+		out := false
+		for i := range cells {
+			day := monday.AddDate(0, 0, i)
+			if day.Before(htime.StartOfDay(from)) || day.After(to) {
+				cells[i] = " "
+				continue
+			}
+
+			// the user's own day, which may not be the asker's one
+			start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc)
+			cells[i] = calendarCell(catalogue, user, start)
+			out = out || cells[i] != "."
+		}
+
+		if out {
+			rows = append(rows, fmt.Sprintf("%-*s %s", calendarNameWidth, calendarName(user), strings.Join(cells, "  ")))
+		}
+	}
+
+	if len(rows) == 0 {
+		return ""
+	}
+
+	var days []string
+	for i := 0; i < 5; i++ {
+		days = append(days, calendarDay(catalogue.Weekday(monday.AddDate(0, 0, i))))
+	}
+
+	header := fmt.Sprintf("%-*s %s", calendarNameWidth, "", strings.Join(days, " "))
+	return catalogue.T("calendar_week", catalogue.Date(monday)) + "\n```\n" + header + "\n" + strings.Join(rows, "\n") + "\n```\n"
+}
+
+// calendarCell is the code of the reason the user is out on the day, in
+// lower case when it's only half of it, or a dot when the user is in
+func calendarCell(catalogue *i18n.Catalogue, user *model.User, start time.Time) string {
+	midday := htime.Midday(start)
+	morning := user.StatusDuring(start, midday.Add(-time.Nanosecond))
+	afternoon := user.StatusDuring(midday, htime.EndOfDay(start))
+
+	switch {
+	case nil == morning && nil == afternoon:
+		return "."
+	case nil == morning:
+		return strings.ToLower(calendarCode(catalogue, afternoon.Reason))
+	case nil == afternoon:
+		return strings.ToLower(calendarCode(catalogue, morning.Reason))
+	case afternoon.Reason.Overrides(morning.Reason):
+		return calendarCode(catalogue, afternoon.Reason)
+	}
+
+	return calendarCode(catalogue, morning.Reason)
+}
+
+func calendarCode(catalogue *i18n.Catalogue, reason model.Reason) string {
+	return catalogue.T("calendar_code_" + strings.Replace(string(reason), " ", "_", -1))
+}
+
+// calendarDay is the weekday name cut or padded to the two columns of a
+// day in the grid
+func calendarDay(weekday string) string {
+	day := []rune(weekday)
+	if len(day) > 2 {
+		day = day[:2]
+	}
+
+	return fmt.Sprintf("%-2s", string(day))
+}
+
+// calendarName is the username, which unlike a mention renders inside a
+// code block, cut to fit the column
+func calendarName(user *model.User) string {
+	name := []rune(user.Username)
+	if len(name) == 0 {
+		name = []rune(user.ID)
+	}
+
+	if len(name) > calendarNameWidth {
+		name = name[:calendarNameWidth]
+	}
+
+	return string(name)
+}
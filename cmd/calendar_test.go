@@ -0,0 +1,248 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/italolelis/hellowork/i18n"
+	"github.com/italolelis/hellowork/model"
+	"github.com/italolelis/hellowork/repo"
+)
+
+// newCalendarRepo has wally in Berlin, a user with a long name in São
+// Paulo and somebody only out on a weekend, around March 2018 and around
+// the new year
+func newCalendarRepo(t *testing.T) repo.Repository {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	saoPaulo, err := time.LoadLocation("America/Sao_Paulo")
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	days := func(loc *time.Location, year int, month time.Month, from int, to int) (time.Time, time.Time) {
+		return time.Date(year, month, from, 0, 0, 0, 0, loc), time.Date(year, month, to+1, 0, 0, 0, 0, loc).Add(-time.Nanosecond)
+	}
+
+	wally := model.NewUser("U1")
+	wally.Username = "wally"
+	wally.Location = "Europe/Berlin"
+	from, to := days(berlin, 2018, time.March, 12, 13)
+	wally.AddStatus(model.NewStatus("", from, to, model.Vacation), "U1")
+	// the afternoon of Thursday
+	wally.AddStatus(model.NewStatus("", time.Date(2018, time.March, 15, 14, 0, 0, 0, berlin), time.Date(2018, time.March, 15, 23, 59, 59, 999999999, berlin), model.Sick), "U1")
+	// remote on Friday morning, then on a work trip
+	wally.AddStatus(model.NewStatus("", time.Date(2018, time.March, 16, 8, 0, 0, 0, berlin), time.Date(2018, time.March, 16, 11, 0, 0, 0, berlin), model.Remote), "U1")
+	from, to = days(berlin, 2018, time.March, 22, 23)
+	wally.AddStatus(model.NewStatus("", from, to, model.WorkTrip), "U1")
+	from, to = days(berlin, 2018, time.December, 27, 31)
+	wally.AddStatus(model.NewStatus("", from, to.AddDate(0, 0, 3), model.Vacation), "U1")
+
+	// out on the Wednesday of São Paulo, which starts on Tuesday evening
+	// and ends on Thursday morning in Berlin
+	long := model.NewUser("U2")
+	long.Username = "a.very.long.username"
+	long.Location = "America/Sao_Paulo"
+	from, to = days(saoPaulo, 2018, time.March, 14, 14)
+	long.AddStatus(model.NewStatus("", from, to, model.OutOfOffice), "U2")
+	from, to = days(saoPaulo, 2019, time.January, 31, 31)
+	long.AddStatus(model.NewStatus("", from, to, model.Sick), "U2")
+
+	weekend := model.NewUser("U3")
+	weekend.Location = "Europe/Berlin"
+	from, to = days(berlin, 2018, time.March, 17, 18)
+	weekend.AddStatus(model.NewStatus("", from, to, model.Vacation), "U3")
+
+	r := repo.NewInMemory()
+	for _, user := range []*model.User{wally, long, weekend} {
+		if err := r.Add(context.Background(), user); nil != err {
+			t.Fatal(err)
+		}
+	}
+
+	return r
+}
+
+func TestCalendar(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	r := newCalendarRepo(t)
+	cases := []struct {
+		name string
+		text string
+		now  time.Time
+		want string
+	}{
+		{
+			name: "this week",
+			text: "who is out this week",
+			now:  time.Date(2018, time.March, 14, 10, 0, 0, 0, berlin),
+			want: "Week of 12/03/2018\n" +
+				"```\n" +
+				"                 Mo Tu We Th Fr\n" +
+				"wally            V  V  .  s  r\n" +
+				"a.very.long.user .  .  O  .  .\n" +
+				"```\n",
+		},
+		{
+			name: "next week",
+			text: "who's out next week?",
+			now:  time.Date(2018, time.March, 14, 10, 0, 0, 0, berlin),
+			want: "Week of 19/03/2018\n" +
+				"```\n" +
+				"                 Mo Tu We Th Fr\n" +
+				"wally            .  .  .  T  T\n" +
+				"```\n",
+		},
+		{
+			name: "next week on a sunday",
+			text: "who is out next week",
+			now:  time.Date(2018, time.March, 11, 10, 0, 0, 0, berlin),
+			want: "Week of 12/03/2018\n" +
+				"```\n" +
+				"                 Mo Tu We Th Fr\n" +
+				"wally            V  V  .  s  r\n" +
+				"a.very.long.user .  .  O  .  .\n" +
+				"```\n",
+		},
+		{
+			name: "a month of next year",
+			text: "who is out in jan?",
+			now:  time.Date(2018, time.December, 10, 10, 0, 0, 0, berlin),
+			want: "Week of 31/12/2018\n" +
+				"```\n" +
+				"                 Mo Tu We Th Fr\n" +
+				"wally               V  V  V  .\n" +
+				"```\n" +
+				"\n" +
+				"Week of 28/01/2019\n" +
+				"```\n" +
+				"                 Mo Tu We Th Fr\n" +
+				"a.very.long.user .  .  .  S   \n" +
+				"```\n",
+		},
+		{
+			name: "nobody out",
+			text: "who is out in april",
+			now:  time.Date(2018, time.March, 14, 10, 0, 0, 0, berlin),
+			want: "",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			conv, _ := newTestConversation(t, i18n.English, "calendar", "U9", "C1", c.text)
+			from, to, ok := calendarPeriod(conv, c.now)
+			if !ok {
+				t.Fatalf("can't read the period of %q", c.text)
+			}
+
+			users, err := r.FindAllOutBetween(context.Background(), from, to)
+			if nil != err {
+				t.Fatal(err)
+			}
+
+			if got := renderCalendar(conv.Catalogue, users, from, to); got != c.want {
+				t.Errorf("got\n%s\nwant\n%s", got, c.want)
+			}
+		})
+	}
+}
+
+func TestCalendarPeriod(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2018, time.March, 14, 10, 0, 0, 0, berlin)
+	cases := []struct {
+		language i18n.Language
+		text     string
+		from     string
+		to       string
+		ok       bool
+	}{
+		{i18n.English, "who is out this week", "2018-03-12", "2018-03-16", true},
+		{i18n.English, "who is out next week", "2018-03-19", "2018-03-23", true},
+		{i18n.English, "who is out in march", "2018-03-01", "2018-03-31", true},
+		{i18n.English, "who is out in February?", "2019-02-01", "2019-02-28", true},
+		{i18n.English, "who is out in Dec", "2018-12-01", "2018-12-31", true},
+		{i18n.English, "who is out in spring", "", "", false},
+	}
+
+	for _, c := range cases {
+		conv, _ := newTestConversation(t, c.language, "calendar", "U9", "C1", c.text)
+		from, to, ok := calendarPeriod(conv, now)
+		if ok != c.ok {
+			t.Errorf("%q: ok is %t", c.text, ok)
+			continue
+		}
+
+		if !ok {
+			continue
+		}
+
+		if got := from.Format("2006-01-02"); got != c.from || !from.Equal(time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, berlin)) {
+			t.Errorf("%q: from %s, want the start of %s", c.text, from, c.from)
+		}
+
+		if got := to.Format("2006-01-02"); got != c.to || to.Hour() != 23 || to.Location() != berlin {
+			t.Errorf("%q: to %s, want the end of %s", c.text, to, c.to)
+		}
+	}
+}
+
+func TestCalendarName(t *testing.T) {
+	cases := []struct {
+		user *model.User
+		want string
+	}{
+		{&model.User{ID: "U1", Username: "wally"}, "wally"},
+		{&model.User{ID: "U1"}, "U1"},
+		{&model.User{ID: "U1", Username: "a.very.long.username"}, "a.very.long.user"},
+		{&model.User{ID: "U1", Username: "jürgen.müller.schön"}, "jürgen.müller.sc"},
+	}
+
+	for _, c := range cases {
+		if got := calendarName(c.user); got != c.want {
+			t.Errorf("got %q, want %q", got, c.want)
+		}
+	}
+}
+
+func TestCalendarDay(t *testing.T) {
+	cases := map[string]string{
+		"Monday":     "Mo",
+		"Donnerstag": "Do",
+		"Úterý":      "Út",
+		"M":          "M ",
+		"":           "  ",
+	}
+
+	for weekday, want := range cases {
+		if got := calendarDay(weekday); got != want {
+			t.Errorf("%q: got %q, want %q", weekday, got, want)
+		}
+	}
+
+	// a catalogue with one letter weekdays still draws the grid
+	catalogue := *i18n.Get(i18n.English)
+	catalogue.Weekdays = [7]string{"S", "M", "T", "W", "T", "F", "S"}
+	monday := time.Date(2018, time.March, 12, 0, 0, 0, 0, time.UTC)
+	user := model.NewUser("U1")
+	user.AddStatus(model.NewStatus("", monday, monday.Add(time.Hour), model.Sick), "U1")
+
+	week := renderWeek(&catalogue, []*model.User{user}, monday, monday, monday.AddDate(0, 0, 5))
+	if !strings.Contains(week, "M  T  W  T  F \n") {
+		t.Errorf("got\n%s", week)
+	}
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"github.com/italolelis/hellowork/model"
+	"github.com/italolelis/hellowork/repo"
+)
+
+// Cancel takes back one of your upcoming statuses, as in "cancel my
+// vacation"
+type Cancel struct {
+	repo repo.Repository
+}
+
+func NewCancel(repo repo.Repository) *Cancel {
+	return &Cancel{repo}
+}
+
+func (c *Cancel) Route() string {
+	return "cancel"
+}
+
+func (c *Cancel) Name() string {
+	return "Cancel status"
+}
+
+func (c *Cancel) Description() string {
+	return "Cancels one of your statuses"
+}
+
+func (c *Cancel) Handle(conv *Conversation) {
+	statusParam, err := conv.Param("status")
+	if nil != err {
+		conv.Say("not_understood")
+		return
+	}
+
+	ctx, cancel := newContext()
+	defer cancel()
+
+	id := conv.Message().UserID
+	user, err := c.repo.Find(ctx, id)
+	switch {
+	case err == repo.ErrNotFound:
+		conv.Say("status_not_found", statusParam)
+		return
+	case nil != err:
+		conv.SayError(err)
+		return
+	}
+
+	status := matchStatus(user, statusParam, conv.Catalogue)
+	if nil == status {
+		conv.Say("status_not_found", statusParam)
+		return
+	}
+
+	err = repo.DeleteStatus(ctx, c.repo, id, status.ID, model.UserID(id))
+	switch {
+	case err == model.ErrStatusNotFound:
+		conv.Say("status_not_found", statusParam)
+	case nil != err:
+		conv.SayError(err)
+	default:
+		loc := user.TimeLocation()
+		conv.Say("status_cancelled", status.Reason, conv.Catalogue.Date(status.From.In(loc)), formatEnd(conv.Catalogue, status, loc))
+	}
+}
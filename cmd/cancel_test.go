@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/italolelis/hellowork/i18n"
+	"github.com/italolelis/hellowork/model"
+)
+
+func TestCancel(t *testing.T) {
+	en := i18n.Get(i18n.English)
+	cases := []struct {
+		user      string
+		text      string
+		reply     string
+		cancelled model.StatusID
+	}{
+		{"U1", "cancel my vacation", en.T("status_cancelled", model.Vacation, "03/03/2031", "07/03/2031"), "aaaaaa11"},
+		{"U1", "cancel status bbbbbb", en.T("status_cancelled", model.Sick, "01/04/2031", en.T("open_end")), "bbbbbb22"},
+		{"U1", "cancel my remote", en.T("status_not_found", "remote"), ""},
+		{"U9", "cancel my vacation", en.T("status_not_found", "vacation"), ""},
+	}
+
+	for _, c := range cases {
+		r, _, _ := newStatusesRepo(t)
+		conv, fake := newTestConversation(t, i18n.English, "cancel", c.user, "D1", c.text)
+		NewCancel(r).Handle(conv)
+
+		if want := []string{c.reply}; !reflect.DeepEqual(fake.replies, want) {
+			t.Errorf("%q: replied %q, want %q", c.text, fake.replies, want)
+		}
+
+		user, err := r.Find(context.Background(), "U1")
+		if nil != err {
+			t.Fatal(err)
+		}
+
+		for _, id := range []model.StatusID{"aaaaaa11", "bbbbbb22"} {
+			if found := nil != user.FindStatus(id); found == (id == c.cancelled) {
+				t.Errorf("%q: status %s found is %t", c.text, id, found)
+			}
+		}
+	}
+}
@@ -1,12 +1,26 @@
 package cmd
 
 import (
-	"github.com/italolelis/hanu"
+	"context"
+	"errors"
+	"time"
+
 	log "github.com/Sirupsen/logrus"
+	"github.com/italolelis/hanu"
+	"github.com/italolelis/hellowork/i18n"
+	"github.com/italolelis/hellowork/repo"
 )
 
+// storageTimeout bounds how long a command waits for the repository before
+// telling the user it can't answer
+const storageTimeout = 5 * time.Second
+
 var commandList []hanu.CommandInterface
 
+var (
+	ErrUnknownParam = errors.New("the route has no such parameter")
+)
+
 type Command interface {
 	Name() string
 	Description() string
@@ -14,15 +28,83 @@ type Command interface {
 	Handler(conv hanu.ConversationInterface)
 }
 
+// LocalizedCommand is a command whose routes come from the i18n catalogues,
+// so it can be addressed and answer in any registered language
+type LocalizedCommand interface {
+	Name() string
+	Description() string
+	// Route is the key of the command's routes in the catalogues
+	Route() string
+	Handle(conv *Conversation)
+}
+
+// Conversation wraps a hanu conversation with the catalogue of the language
+// the message was written in
+type Conversation struct {
+	hanu.ConversationInterface
+	Catalogue *i18n.Catalogue
+	route     i18n.Route
+}
+
+// Param returns the text matched by the named group of the route
+func (c *Conversation) Param(name string) (string, error) {
+	for i, param := range c.route.Params {
+		if param == name {
+			return c.Match(i)
+		}
+	}
+
+	return "", ErrUnknownParam
+}
+
+// Say replies with a message from the catalogue
+func (c *Conversation) Say(id string, args ...interface{}) {
+	c.Reply(c.Catalogue.T(id, args...))
+}
+
+// SayError tells the user the bot can't answer right now, rather than
+// guessing from data it couldn't load
+func (c *Conversation) SayError(err error) {
+	log.WithError(err).Error("Could not reach the storage")
+	if repo.IsTransient(err) {
+		c.Say("storage_unavailable")
+		return
+	}
+
+	c.Say("storage_error")
+}
+
+// newContext returns the context the commands use to talk to the repository
+func newContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), storageTimeout)
+}
+
 // Register adds a new command to commandList
 func Register(command Command) {
-	log.Debugf("% command registered", command.Name())
+	log.Debugf("%s command registered", command.Name())
 	cmds := command.Commands()
 	for _, route := range cmds {
 		commandList = append(commandList, hanu.NewCommand(command.Name(), command.Description(), route, command.Handler))
 	}
 }
 
+// RegisterLocalized adds a new command to commandList for every route of
+// every catalogue
+func RegisterLocalized(command LocalizedCommand) {
+	log.Debugf("%s command registered", command.Name())
+	for _, catalogue := range i18n.Catalogues() {
+		for _, route := range catalogue.Routes[command.Route()] {
+			commandList = append(commandList, hanu.NewCommand(command.Name(), command.Description(), route.Pattern, handlerFor(command, catalogue, route)))
+		}
+	}
+}
+
+func handlerFor(command LocalizedCommand, catalogue *i18n.Catalogue, route i18n.Route) func(hanu.ConversationInterface) {
+	return func(conv hanu.ConversationInterface) {
+		command.Handle(&Conversation{conv, catalogue, route})
+	}
+}
+
 // List returns commandList
 func List() []hanu.CommandInterface {
 	return commandList
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/italolelis/hanu"
+	"github.com/italolelis/hellowork/i18n"
+)
+
+// fakeConversation records the replies to a message instead of posting
+// them. The rest of the hanu conversation is left out
+type fakeConversation struct {
+	hanu.ConversationInterface
+	message hanu.Message
+	matches []string
+	replies []string
+}
+
+func (c *fakeConversation) Match(position int) (string, error) {
+	if position >= len(c.matches) {
+		return "", errors.New("no such match")
+	}
+
+	return c.matches[position], nil
+}
+
+func (c *fakeConversation) Reply(text string, a ...interface{}) {
+	if len(a) > 0 {
+		text = fmt.Sprintf(text, a...)
+	}
+
+	c.replies = append(c.replies, text)
+}
+
+func (c *fakeConversation) Message() hanu.Message {
+	return c.message
+}
+
+// newTestConversation is the conversation of the user's message in the
+// channel, matched against the first route of the command it fits
+func newTestConversation(t *testing.T, language i18n.Language, command string, user string, channel string, text string) (*Conversation, *fakeConversation) {
+	catalogue := i18n.Get(language)
+	for _, route := range catalogue.Routes[command] {
+		match := regexp.MustCompile("^" + route.Pattern + "$").FindStringSubmatch(text)
+		if nil == match {
+			continue
+		}
+
+		fake := &fakeConversation{
+			message: hanu.Message{UserID: user, Channel: channel, Message: text},
+			matches: match[1:],
+		}
+
+		return &Conversation{fake, catalogue, route}, fake
+	}
+
+	t.Fatalf("%q doesn't match any route of %s", text, command)
+	return nil, nil
+}
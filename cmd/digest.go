@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"regexp"
+	"strconv"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/italolelis/hellowork/model"
+	"github.com/italolelis/hellowork/repo"
+	"github.com/nlopes/slack"
+)
+
+var digestTimePattern = regexp.MustCompile(`^(\d{1,2})(?:[:.h](\d{2}))?$`)
+
+// Digest sets up the daily summary of who is out in the channel it's asked
+// in, at the given time of the day of who asked
+type Digest struct {
+	client *slack.Client
+	repo   repo.DigestRepository
+}
+
+func NewDigest(client *slack.Client, repo repo.DigestRepository) *Digest {
+	return &Digest{client, repo}
+}
+
+func (c *Digest) Route() string {
+	return "digest"
+}
+
+func (c *Digest) Name() string {
+	return "Digest"
+}
+
+func (c *Digest) Description() string {
+	return "Posts who is out in the channel every working day"
+}
+
+func (c *Digest) Handle(conv *Conversation) {
+	at, err := conv.Param("time")
+	if nil != err {
+		conv.Say("not_understood")
+		return
+	}
+
+	hour, minute, ok := parseTimeOfDay(at)
+	if !ok {
+		conv.Say("digest_invalid_time", at)
+		return
+	}
+
+	slackUser, err := c.client.GetUserInfo(conv.Message().UserID)
+	if nil != err {
+		log.Error(err)
+		conv.Say("not_understood")
+		return
+	}
+
+	digest := &model.Digest{
+		Channel:  conv.Message().Channel,
+		Hour:     hour,
+		Minute:   minute,
+		Location: slackUser.TZ,
+		Language: string(conv.Catalogue.Language),
+		LastSent: time.Now(),
+	}
+
+	ctx, cancel := newContext()
+	defer cancel()
+
+	if err := c.repo.AddDigest(ctx, digest); nil != err {
+		conv.SayError(err)
+		return
+	}
+
+	next := digest.Next(digest.LastSent)
+	conv.Say("digest_scheduled", next.Format("15:04"), conv.Catalogue.Weekday(next), conv.Catalogue.Date(next))
+}
+
+// parseTimeOfDay reads times such as "9", "09:30" or "9.30"
+func parseTimeOfDay(text string) (int, int, bool) {
+	match := digestTimePattern.FindStringSubmatch(text)
+	if nil == match {
+		return 0, 0, false
+	}
+
+	hour, _ := strconv.Atoi(match[1])
+	minute, _ := strconv.Atoi(match[2])
+	if hour > 23 || minute > 59 {
+		return 0, 0, false
+	}
+
+	return hour, minute, true
+}
+
+// StopDigest stops posting the daily summary in the channel
+type StopDigest struct {
+	repo repo.DigestRepository
+}
+
+func NewStopDigest(repo repo.DigestRepository) *StopDigest {
+	return &StopDigest{repo}
+}
+
+func (c *StopDigest) Route() string {
+	return "stop_digest"
+}
+
+func (c *StopDigest) Name() string {
+	return "Stop digest"
+}
+
+func (c *StopDigest) Description() string {
+	return "Stops posting who is out in the channel"
+}
+
+func (c *StopDigest) Handle(conv *Conversation) {
+	ctx, cancel := newContext()
+	defer cancel()
+
+	err := c.repo.RemoveDigest(ctx, conv.Message().Channel)
+	switch {
+	case err == repo.ErrNotFound:
+		conv.Say("digest_not_found")
+	case nil != err:
+		conv.SayError(err)
+	default:
+		conv.Say("digest_stopped")
+	}
+}
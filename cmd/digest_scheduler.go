@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/italolelis/hellowork/i18n"
+	"github.com/italolelis/hellowork/model"
+	"github.com/italolelis/hellowork/repo"
+	htime "github.com/italolelis/hellowork/time"
+)
+
+const (
+	// digestInterval is how often the digests are checked
+	digestInterval = time.Minute
+	// digestGrace is how late a digest is still worth posting, such as
+	// after a restart, before it's skipped for the day
+	digestGrace = time.Hour
+)
+
+// DigestScheduler posts the digests when they are due: who is out today,
+// who leaves on the next working day and who is back today
+type DigestScheduler struct {
+	storage  repo.Storage
+	notifier Notifier
+}
+
+func NewDigestScheduler(storage repo.Storage, notifier Notifier) *DigestScheduler {
+	return &DigestScheduler{storage, notifier}
+}
+
+// Run posts the due digests right away and then at every interval
+func (s *DigestScheduler) Run() {
+	ticker := time.NewTicker(digestInterval)
+	defer ticker.Stop()
+
+	for {
+		s.Post(time.Now())
+		<-ticker.C
+	}
+}
+
+// Post posts the digests due at the given time. A digest that couldn't be
+// posted is tried again on the next run
+func (s *DigestScheduler) Post(now time.Time) {
+	ctx, cancel := newContext()
+	defer cancel()
+
+	digests, err := s.storage.FindAllDigests(ctx)
+	if nil != err {
+		log.WithError(err).Warn("Could not load the digests")
+		return
+	}
+
+	for _, digest := range digests {
+		if !digest.IsDue(now) {
+			continue
+		}
+
+		logger := log.WithField("channel", digest.Channel)
+		if now.Sub(digest.Next(digest.LastSent)) <= digestGrace {
+			text, err := s.render(ctx, digest, now)
+			if nil != err {
+				logger.WithError(err).Warn("Could not build the digest")
+				continue
+			}
+
+			if err := s.notifier.Notify(digest.Channel, text); nil != err {
+				logger.WithError(err).Warn("Could not post the digest")
+				continue
+			}
+		}
+
+		if err := s.storage.DigestSent(ctx, digest.Channel, now); nil != err && err != repo.ErrNotFound {
+			logger.WithError(err).Warn("Could not record the digest was posted")
+		}
+	}
+}
+
+// render writes the digest for the day of now in the digest's time zone
+func (s *DigestScheduler) render(ctx context.Context, digest *model.Digest, now time.Time) (string, error) {
+	catalogue := i18n.Get(i18n.Language(digest.Language))
+	today := now.In(digest.TimeLocation())
+
+	out, err := s.storage.FindAllOut(ctx, today)
+	if nil != err {
+		return "", err
+	}
+
+	isOut := make(map[model.UserID]bool)
+	var outLines []string
+	for _, user := range out {
+		isOut[user.ID] = true
+		outLines = append(outLines, digestLine(catalogue, user, user.StatusAt(today)))
+	}
+
+	// people out on the next working day who aren't already
+	next := htime.StartOfDay(htime.AddWeekday(today))
+	leaving, err := s.storage.FindAllOutBetween(ctx, next, htime.EndOfDay(next))
+	if nil != err {
+		return "", err
+	}
+
+	var leavingLines []string
+	for _, user := range leaving {
+		if isOut[user.ID] {
+			continue
+		}
+
+		if status := user.StatusAt(next); nil != status {
+			leavingLines = append(leavingLines, digestLine(catalogue, user, status))
+		}
+	}
+
+	// people out at the end of the last working day who aren't anymore
+	last := htime.EndOfDay(htime.SubWeekday(today))
+	returning, err := s.storage.FindAllOut(ctx, last)
+	if nil != err {
+		return "", err
+	}
+
+	var returningLines []string
+	for _, user := range returning {
+		if !isOut[user.ID] {
+			returningLines = append(returningLines, catalogue.T("digest_returning_line", user.ID, user.StatusAt(last).Reason))
+		}
+	}
+
+	if len(outLines)+len(leavingLines)+len(returningLines) == 0 {
+		return catalogue.T("digest_nobody", catalogue.Weekday(today), catalogue.Date(today)), nil
+	}
+
+	sections := []string{catalogue.T("digest", catalogue.Weekday(today), catalogue.Date(today))}
+	if len(outLines) > 0 {
+		sections = append(sections, catalogue.T("digest_out")+"\n"+strings.Join(outLines, "\n"))
+	}
+
+	if len(leavingLines) > 0 {
+		sections = append(sections, catalogue.T("digest_leaving", catalogue.Weekday(next))+"\n"+strings.Join(leavingLines, "\n"))
+	}
+
+	if len(returningLines) > 0 {
+		sections = append(sections, catalogue.T("digest_returning")+"\n"+strings.Join(returningLines, "\n"))
+	}
+
+	return strings.Join(sections, "\n\n"), nil
+}
+
+func digestLine(catalogue *i18n.Catalogue, user *model.User, status *model.Status) string {
+	to := status.To.In(user.TimeLocation())
+	return catalogue.T("digest_line", user.ID, status.Reason, catalogue.Weekday(to), catalogue.Date(to))
+}
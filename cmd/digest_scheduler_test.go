@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/italolelis/hellowork/model"
+	"github.com/italolelis/hellowork/repo"
+)
+
+// newDigestRepo has a digest at 9:30 in Berlin last sent on Friday the 16th
+// of March 2018, somebody out from that Monday, somebody leaving on the
+// Tuesday, somebody back from the Friday and somebody out on the weekend
+func newDigestRepo(t *testing.T, berlin *time.Location) repo.Storage {
+	ctx := context.Background()
+	r := repo.NewInMemory()
+
+	day := func(d int) time.Time {
+		return time.Date(2018, time.March, d, 0, 0, 0, 0, berlin)
+	}
+	end := func(d int) time.Time {
+		return day(d).AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}
+
+	for id, status := range map[model.UserID]*model.Status{
+		"U1": model.NewStatus("", day(19), end(21), model.Vacation),
+		"U2": model.NewStatus("", day(20), end(20), model.Sick),
+		"U3": model.NewStatus("", day(15), end(16), model.WorkTrip),
+		"U4": model.NewStatus("", day(17), end(18), model.OutOfOffice),
+	} {
+		user := model.NewUser(id)
+		user.Location = "Europe/Berlin"
+		user.AddStatus(status, id)
+		if err := r.Add(ctx, user); nil != err {
+			t.Fatal(err)
+		}
+	}
+
+	digest := &model.Digest{Channel: "C1", Hour: 9, Minute: 30, Location: "Europe/Berlin", Language: "en", LastSent: time.Date(2018, time.March, 16, 9, 30, 0, 0, berlin)}
+	if err := r.AddDigest(ctx, digest); nil != err {
+		t.Fatal(err)
+	}
+
+	return r
+}
+
+func TestDigestScheduler(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	digest := strings.Join([]string{
+		"C1: Who is out on Monday (19/03/2018)",
+		"",
+		"*Out today*",
+		"<@U1> vacation until Wednesday (21/03/2018)",
+		"",
+		"*Leaving on Tuesday*",
+		"<@U2> sick until Tuesday (20/03/2018)",
+		"",
+		"*Back today*",
+		"<@U3> from work trip",
+	}, "\n")
+
+	cases := []struct {
+		name     string
+		now      time.Time
+		sent     []string
+		lastSent time.Time
+	}{
+		{
+			name:     "on the weekend",
+			now:      time.Date(2018, time.March, 17, 9, 30, 0, 0, berlin),
+			lastSent: time.Date(2018, time.March, 16, 9, 30, 0, 0, berlin),
+		},
+		{
+			name:     "monday before the time",
+			now:      time.Date(2018, time.March, 19, 9, 29, 0, 0, berlin),
+			lastSent: time.Date(2018, time.March, 16, 9, 30, 0, 0, berlin),
+		},
+		{
+			name:     "monday at the time",
+			now:      time.Date(2018, time.March, 19, 9, 30, 0, 0, berlin),
+			sent:     []string{digest},
+			lastSent: time.Date(2018, time.March, 19, 9, 30, 0, 0, berlin),
+		},
+		{
+			name:     "late within the grace",
+			now:      time.Date(2018, time.March, 19, 10, 30, 0, 0, berlin),
+			sent:     []string{digest},
+			lastSent: time.Date(2018, time.March, 19, 10, 30, 0, 0, berlin),
+		},
+		{
+			name:     "too late",
+			now:      time.Date(2018, time.March, 19, 10, 31, 0, 0, berlin),
+			lastSent: time.Date(2018, time.March, 19, 10, 31, 0, 0, berlin),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := newDigestRepo(t, berlin)
+			notifier := &fakeNotifier{}
+			NewDigestScheduler(r, notifier).Post(c.now)
+
+			if !reflect.DeepEqual(notifier.sent, c.sent) {
+				t.Errorf("sent %q, want %q", notifier.sent, c.sent)
+			}
+
+			digests, err := r.FindAllDigests(context.Background())
+			if nil != err {
+				t.Fatal(err)
+			}
+
+			if !digests[0].LastSent.Equal(c.lastSent) {
+				t.Errorf("last sent %s, want %s", digests[0].LastSent, c.lastSent)
+			}
+		})
+	}
+}
+
+func TestDigestSchedulerNobodyOut(t *testing.T) {
+	ctx := context.Background()
+	r := repo.NewInMemory()
+	digest := &model.Digest{Channel: "C1", Hour: 9, Location: "UTC", Language: "de", LastSent: time.Date(2018, time.March, 16, 9, 0, 0, 0, time.UTC)}
+	if err := r.AddDigest(ctx, digest); nil != err {
+		t.Fatal(err)
+	}
+
+	notifier := &fakeNotifier{}
+	NewDigestScheduler(r, notifier).Post(time.Date(2018, time.March, 19, 9, 0, 0, 0, time.UTC))
+
+	if want := []string{"C1: Am Montag (19.03.2018) sind alle da"}; !reflect.DeepEqual(notifier.sent, want) {
+		t.Errorf("sent %q, want %q", notifier.sent, want)
+	}
+}
+
+func TestParseTimeOfDay(t *testing.T) {
+	cases := []struct {
+		text   string
+		hour   int
+		minute int
+		ok     bool
+	}{
+		{"9", 9, 0, true},
+		{"09:30", 9, 30, true},
+		{"9.30", 9, 30, true},
+		{"17h45", 17, 45, true},
+		{"23:59", 23, 59, true},
+		{"24", 0, 0, false},
+		{"9:60", 0, 0, false},
+		{"9:5", 0, 0, false},
+		{"nine", 0, 0, false},
+		{"", 0, 0, false},
+	}
+
+	for _, c := range cases {
+		hour, minute, ok := parseTimeOfDay(c.text)
+		if hour != c.hour || minute != c.minute || ok != c.ok {
+			t.Errorf("%q: got %d, %d, %t", c.text, hour, minute, ok)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/italolelis/hellowork/web"
+)
+
+var subteamPattern = regexp.MustCompile(`<!subteam\^([A-Za-z0-9]+)(?:\|[^>]*)?>`)
+
+// Feed gives out the address of a calendar feed, of yourself, of somebody,
+// of a user group or of everybody. Whoever has the address can read the
+// feed, so it's only given in direct messages
+type Feed struct {
+	feeds *web.Feeds
+}
+
+func NewFeed(feeds *web.Feeds) *Feed {
+	return &Feed{feeds}
+}
+
+func (c *Feed) Route() string {
+	return "feed"
+}
+
+func (c *Feed) Name() string {
+	return "Feed"
+}
+
+func (c *Feed) Description() string {
+	return "Gives you a calendar feed of who is out"
+}
+
+func (c *Feed) Handle(conv *Conversation) {
+	if !isDirectMessage(conv.Message().Channel) {
+		conv.Say("feed_private")
+		return
+	}
+
+	userParam, err := NewUserParam(conv)
+	if nil != err || nil == userParam {
+		conv.Say("not_understood")
+		return
+	}
+
+	var feed string
+	switch {
+	case userParam.isEverybody():
+		feed = web.AllFeed
+	case strings.EqualFold(conv.Catalogue.Normalize(userParam.Param), "me"):
+		feed = web.UserFeed(conv.Message().UserID)
+	case subteamPattern.MatchString(userParam.Param):
+		feed = web.TeamFeed(subteamPattern.FindStringSubmatch(userParam.Param)[1])
+	case userParam.Pattern.MatchString(userParam.Param):
+		feed = web.UserFeed(userParam.GetUserID())
+	default:
+		conv.Say("not_understood_word", userParam.Param)
+		return
+	}
+
+	conv.Say("feed_url", c.feeds.URL(feed))
+}
@@ -0,0 +1,253 @@
+package cmd
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/italolelis/hellowork/i18n"
+	"github.com/italolelis/hellowork/model"
+	"github.com/italolelis/hellowork/model/dateparse"
+	"github.com/italolelis/hellowork/repo"
+	"github.com/nlopes/slack"
+)
+
+// followupTimeout is how long a question waits for its answer. After that
+// the status stays open ended, as if the question was never asked
+const followupTimeout = 10 * time.Minute
+
+type followupState int
+
+const (
+	// awaitingUntil waits for when the user will be back
+	awaitingUntil followupState = iota
+	// awaitingConfirmation waits for a yes or a no about the proposed end
+	awaitingConfirmation
+)
+
+// followup is a question the bot asked about a status
+type followup struct {
+	state     followupState
+	user      string
+	status    model.StatusID
+	reason    model.Reason
+	from      time.Time
+	to        time.Time
+	catalogue *i18n.Catalogue
+	expires   time.Time
+}
+
+// Followups keeps the questions waiting for an answer, at most one per user
+// and channel, and takes the next answer of the user there
+type Followups struct {
+	repo     repo.Repository
+	notifier Notifier
+	commands []*regexp.Regexp
+	now      func() time.Time
+
+	mu      sync.Mutex
+	pending map[string]*followup
+}
+
+func NewFollowups(repo repo.Repository, notifier Notifier) *Followups {
+	f := &Followups{repo: repo, notifier: notifier, now: time.Now, pending: make(map[string]*followup)}
+	for _, catalogue := range i18n.Catalogues() {
+		for _, routes := range catalogue.Routes {
+			for _, route := range routes {
+				f.commands = append(f.commands, regexp.MustCompile(`^(?:`+route.Pattern+`)$`))
+			}
+		}
+	}
+
+	return f
+}
+
+// AskUntil waits for when the user will be back from the status, which was
+// saved without an until
+func (f *Followups) AskUntil(user string, channel string, status *model.Status, catalogue *i18n.Catalogue) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.pending[followupKey(user, channel)] = &followup{
+		state:     awaitingUntil,
+		user:      user,
+		status:    status.ID,
+		reason:    status.Reason,
+		from:      status.From,
+		catalogue: catalogue,
+		expires:   f.now().Add(followupTimeout),
+	}
+}
+
+// HandleMessage answers the question waiting in the channel, if any. In a
+// channel the user doesn't have to mention the bot to answer, but then the
+// message has to be nothing but the answer, anything else is left alone
+func (f *Followups) HandleMessage(msg *slack.MessageEvent, botID string) {
+	addressed := isDirectMessage(msg.Channel) || strings.Contains(msg.Text, "<@"+botID+">")
+	text := strings.TrimSpace(strings.Replace(msg.Text, "<@"+botID+">", "", -1))
+	text = strings.TrimSpace(strings.TrimPrefix(text, ":"))
+	if f.isCommand(text) {
+		return
+	}
+
+	key := followupKey(msg.User, msg.Channel)
+	pending := f.take(key, func(pending *followup) bool {
+		return addressed || isBareAnswer(pending, text)
+	})
+	if nil == pending {
+		return
+	}
+
+	next := f.answer(pending, text, msg.Channel)
+	if nil != next {
+		f.mu.Lock()
+		f.pending[key] = next
+		f.mu.Unlock()
+	}
+}
+
+// isBareAnswer reports whether the text is only a date while waiting for
+// when the user is back, or only a yes or a no while waiting for a
+// confirmation
+func isBareAnswer(pending *followup, text string) bool {
+	text = pending.catalogue.Normalize(text)
+	if pending.state == awaitingConfirmation {
+		word := strings.ToLower(strings.Trim(text, ".!? "))
+		return word == "yes" || word == "no"
+	}
+
+	_, err := dateparse.ParseRange(strings.TrimRight(text, "!? "))
+	return nil == err
+}
+
+// answer moves the question to its next state, returning nil once it's
+// settled
+func (f *Followups) answer(pending *followup, text string, channel string) *followup {
+	catalogue := pending.catalogue
+	word := strings.ToLower(strings.Trim(catalogue.Normalize(text), ".!? "))
+	if word == "cancel" {
+		f.say(pending, channel, "followup_cancelled")
+		return nil
+	}
+
+	switch pending.state {
+	case awaitingConfirmation:
+		switch word {
+		case "yes":
+			f.settle(pending, channel)
+			return nil
+		case "no":
+			f.say(pending, channel, "ask_until")
+			pending.state = awaitingUntil
+		default:
+			f.say(pending, channel, "confirm_until", catalogue.Weekday(pending.to), catalogue.Date(pending.to))
+		}
+	case awaitingUntil:
+		to, ambiguous, err := model.NewEndMention(catalogue.Normalize(text), pending.from)
+		switch {
+		case err == model.ErrTooFarAhead:
+			f.say(pending, channel, "date_too_far")
+		case nil != err:
+			f.say(pending, channel, "not_understood_until")
+		case to.Before(pending.from):
+			f.say(pending, channel, "status_invalid_period")
+		case ambiguous:
+			pending.to = to
+			pending.state = awaitingConfirmation
+			f.say(pending, channel, "confirm_until", catalogue.Weekday(to), catalogue.Date(to))
+		default:
+			pending.to = to
+			f.settle(pending, channel)
+			return nil
+		}
+	}
+
+	return pending
+}
+
+// settle saves the end of the status
+func (f *Followups) settle(pending *followup, channel string) {
+	ctx, cancel := newContext()
+	defer cancel()
+
+	var status *model.Status
+	err := repo.Update(ctx, f.repo, pending.user, func(user *model.User) error {
+		found := user.FindStatus(pending.status)
+		if nil == found {
+			return model.ErrStatusNotFound
+		}
+
+		ended := *found
+		ended.To = pending.to
+		status = &ended
+		return user.EditStatus(status, model.UserID(pending.user))
+	})
+
+	switch {
+	case err == repo.ErrNotFound || err == model.ErrStatusNotFound:
+		f.say(pending, channel, "status_not_found", pending.reason)
+	case nil != err:
+		log.WithError(err).Error("Could not save when the user is back")
+		if repo.IsTransient(err) {
+			f.say(pending, channel, "storage_unavailable")
+		} else {
+			f.say(pending, channel, "storage_error")
+		}
+	default:
+		f.say(pending, channel, "status_created", pending.catalogue.Date(status.From), pending.catalogue.Date(status.To))
+	}
+}
+
+// take removes the question waiting for the key when the message accepts
+// it, dropping the expired ones
+func (f *Followups) take(key string, accepts func(*followup) bool) *followup {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	now := f.now()
+	for k, pending := range f.pending {
+		if now.After(pending.expires) {
+			delete(f.pending, k)
+		}
+	}
+
+	pending := f.pending[key]
+	if nil == pending || !accepts(pending) {
+		return nil
+	}
+
+	delete(f.pending, key)
+	return pending
+}
+
+// isCommand reports whether the text is meant for one of the commands
+// rather than being an answer
+func (f *Followups) isCommand(text string) bool {
+	for _, command := range f.commands {
+		if command.MatchString(text) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// say replies the way hanu does, mentioning the user outside of direct
+// messages. Asking again gives the user the whole timeout to answer
+func (f *Followups) say(pending *followup, channel string, id string, args ...interface{}) {
+	pending.expires = f.now().Add(followupTimeout)
+	text := pending.catalogue.T(id, args...)
+	if !isDirectMessage(channel) {
+		text = "<@" + pending.user + "> " + text
+	}
+
+	if err := f.notifier.Notify(channel, text); nil != err {
+		log.WithError(err).WithField("channel", channel).Warn("Could not answer the follow up")
+	}
+}
+
+func followupKey(user string, channel string) string {
+	return user + "/" + channel
+}
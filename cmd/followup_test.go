@@ -0,0 +1,208 @@
+package cmd
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/italolelis/hellowork/i18n"
+	"github.com/italolelis/hellowork/model"
+	"github.com/italolelis/hellowork/repo"
+	"github.com/nlopes/slack"
+)
+
+// followupMessage is a message seen after the question was asked, after is
+// how long after the previous one it's sent
+type followupMessage struct {
+	user  string
+	text  string
+	after time.Duration
+}
+
+func TestFollowups(t *testing.T) {
+	en := i18n.Get(i18n.English)
+	asked := "<@U1> " + en.T("confirm_until", "Friday", "08/03/2030")
+	created := func(until string) string {
+		return "<@U1> " + en.T("status_created", "04/03/2030", until)
+	}
+
+	cases := []struct {
+		name     string
+		channel  string
+		messages []followupMessage
+		sent     []string
+		until    string
+		pending  bool
+	}{
+		{
+			name:     "until in a direct message",
+			channel:  "D1",
+			messages: []followupMessage{{"U1", "until 8/3/2030", 0}},
+			sent:     []string{en.T("status_created", "04/03/2030", "08/03/2030")},
+			until:    "2030-03-08 23:59",
+		},
+		{
+			name:     "bare until in a channel",
+			channel:  "C1",
+			messages: []followupMessage{{"U1", "until 8/3/2030", 0}},
+			sent:     []string{created("08/03/2030")},
+			until:    "2030-03-08 23:59",
+		},
+		{
+			name:     "back on a day",
+			channel:  "C1",
+			messages: []followupMessage{{"U1", "<@B> probably back on 11/3/2030", 0}},
+			sent:     []string{created("10/03/2030")},
+			until:    "2030-03-10 23:59",
+		},
+		{
+			name:     "ambiguous date confirmed",
+			channel:  "C1",
+			messages: []followupMessage{{"U1", "8/3/2030", 0}, {"U1", "yes", 0}},
+			sent:     []string{asked, created("08/03/2030")},
+			until:    "2030-03-08 23:59",
+		},
+		{
+			name:    "ambiguous date refused",
+			channel: "C1",
+			messages: []followupMessage{
+				{"U1", "8/3/2030", 0},
+				{"U1", "no", 0},
+				{"U1", "for 2 days", 0},
+			},
+			sent:  []string{asked, "<@U1> " + en.T("ask_until"), created("05/03/2030")},
+			until: "2030-03-05 23:59",
+		},
+		{
+			name:     "something else while confirming",
+			channel:  "C1",
+			messages: []followupMessage{{"U1", "8/3/2030", 0}, {"U1", "<@B> maybe", 0}},
+			sent:     []string{asked, asked},
+			pending:  true,
+		},
+		{
+			name:     "cancelled",
+			channel:  "C1",
+			messages: []followupMessage{{"U1", "<@B> cancel", 0}},
+			sent:     []string{"<@U1> " + en.T("followup_cancelled")},
+		},
+		{
+			name:     "cancel in a direct message",
+			channel:  "D1",
+			messages: []followupMessage{{"U1", "cancel", 0}},
+			sent:     []string{en.T("followup_cancelled")},
+		},
+		{
+			name:    "chatting in the channel",
+			channel: "C1",
+			messages: []followupMessage{
+				{"U1", "cancel", 0},
+				{"U1", "let's cancel the meeting", 0},
+				{"U1", "the release is until 8/3/2030", 0},
+				{"U1", "until 1/1/2300 or so", 0},
+				{"U1", "yes", 0},
+			},
+			pending: true,
+		},
+		{
+			name:     "answer of somebody else",
+			channel:  "C1",
+			messages: []followupMessage{{"U2", "until 8/3/2030", 0}, {"U2", "<@B> cancel", 0}},
+			pending:  true,
+		},
+		{
+			name:     "not understood",
+			channel:  "C1",
+			messages: []followupMessage{{"U1", "<@B> whenever", 0}},
+			sent:     []string{"<@U1> " + en.T("not_understood_until")},
+			pending:  true,
+		},
+		{
+			name:     "command instead of an answer",
+			channel:  "C1",
+			messages: []followupMessage{{"U1", "<@B> where is <@U2>", 0}},
+			pending:  true,
+		},
+		{
+			name:     "too far ahead",
+			channel:  "C1",
+			messages: []followupMessage{{"U1", "until 1/1/2300", 0}},
+			sent:     []string{"<@U1> " + en.T("date_too_far")},
+			pending:  true,
+		},
+		{
+			name:     "before the start",
+			channel:  "C1",
+			messages: []followupMessage{{"U1", "<@B> until 1/3/2030", 0}},
+			sent:     []string{"<@U1> " + en.T("status_invalid_period")},
+			pending:  true,
+		},
+		{
+			name:     "answer after the timeout",
+			channel:  "C1",
+			messages: []followupMessage{{"U1", "until 8/3/2030", followupTimeout + time.Second}},
+		},
+		{
+			name:    "asking again extends the timeout",
+			channel: "C1",
+			messages: []followupMessage{
+				{"U1", "<@B> whenever", followupTimeout - time.Second},
+				{"U1", "until 8/3/2030", followupTimeout - time.Second},
+			},
+			sent:  []string{"<@U1> " + en.T("not_understood_until"), created("08/03/2030")},
+			until: "2030-03-08 23:59",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctx := context.Background()
+			r := repo.NewInMemory()
+			user := model.NewUser("U1")
+			status := model.NewStatus("", time.Date(2030, time.March, 4, 0, 0, 0, 0, time.UTC), time.Time{}, model.Vacation)
+			user.AddStatus(status, "U1")
+			if err := r.Add(ctx, user); nil != err {
+				t.Fatal(err)
+			}
+
+			now := time.Date(2030, time.March, 1, 9, 0, 0, 0, time.UTC)
+			notifier := &fakeNotifier{}
+			f := NewFollowups(r, notifier)
+			f.now = func() time.Time { return now }
+			f.AskUntil("U1", c.channel, status, en)
+
+			for _, m := range c.messages {
+				now = now.Add(m.after)
+				f.HandleMessage(&slack.MessageEvent{Msg: slack.Msg{Channel: c.channel, User: m.user, Text: m.text}}, "B")
+			}
+
+			var sent []string
+			for _, text := range c.sent {
+				sent = append(sent, c.channel+": "+text)
+			}
+
+			if !reflect.DeepEqual(notifier.sent, sent) {
+				t.Errorf("sent %q, want %q", notifier.sent, sent)
+			}
+
+			user, err := r.Find(ctx, "U1")
+			if nil != err {
+				t.Fatal(err)
+			}
+
+			until := ""
+			if to := user.Statuses[0].To; !to.IsZero() {
+				until = to.Format("2006-01-02 15:04")
+			}
+
+			if until != c.until {
+				t.Errorf("status until %q, want %q", until, c.until)
+			}
+
+			if _, pending := f.pending[followupKey("U1", c.channel)]; pending != c.pending {
+				t.Errorf("pending is %t, want %t", pending, c.pending)
+			}
+		})
+	}
+}
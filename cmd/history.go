@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/italolelis/hellowork/model"
+	"github.com/italolelis/hellowork/repo"
+	"github.com/nlopes/slack"
+)
+
+// History lists every change made to the statuses of a user. Everybody can
+// see their own history, only admins can see the one of somebody else
+type History struct {
+	client *slack.Client
+	repo   repo.Repository
+}
+
+func NewHistory(client *slack.Client, repo repo.Repository) *History {
+	return &History{client, repo}
+}
+
+func (c *History) Route() string {
+	return "history"
+}
+
+func (c *History) Name() string {
+	return "History"
+}
+
+func (c *History) Description() string {
+	return "Shows every change made to the statuses of an user"
+}
+
+func (c *History) Handle(conv *Conversation) {
+	userParam, err := NewUserParam(conv)
+	if nil != err || nil == userParam {
+		log.Error(err)
+		conv.Say("not_understood")
+		return
+	}
+
+	asker, err := c.client.GetUserInfo(conv.Message().UserID)
+	if nil != err {
+		log.Error(err)
+		conv.Say("not_understood")
+		return
+	}
+
+	id := userParam.GetUserID()
+	if id != asker.ID && !asker.IsAdmin && !asker.IsOwner {
+		conv.Say("history_forbidden")
+		return
+	}
+
+	ctx, cancel := newContext()
+	defer cancel()
+
+	events, err := c.repo.History(ctx, id)
+	switch {
+	case err == repo.ErrNotFound || (nil == err && len(events) == 0):
+		conv.Say("history_empty", userParam.Param)
+		return
+	case nil != err:
+		conv.SayError(err)
+		return
+	}
+
+	loc := (&model.User{Location: asker.TZ}).TimeLocation()
+	lines := make([]string, len(events))
+	for i, event := range events {
+		at := event.At.In(loc)
+		lines[i] = conv.Catalogue.T("history_"+string(event.Type), conv.Catalogue.Date(at)+" "+conv.Catalogue.Time(at),
+			event.Author, event.Status.Reason, conv.Catalogue.Date(event.Status.From), conv.Catalogue.Date(event.Status.To))
+	}
+
+	conv.Reply(conv.Catalogue.T("history", userParam.Param) + strings.Join(lines, "\n"))
+}
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/italolelis/hellowork/importer"
+	"github.com/italolelis/hellowork/model"
+	"github.com/italolelis/hellowork/repo"
+	"github.com/nlopes/slack"
+)
+
+// importTimeout bounds the download of a calendar and the import
+const importTimeout = 30 * time.Second
+
+// errNothingImported skips saving the user when the calendar brought nothing
+// new
+var errNothingImported = errors.New("nothing imported")
+
+// ImportCalendar imports the absences booked in your calendar from the
+// address of its iCalendar file. Such addresses are secret, so they are only
+// accepted in direct messages
+type ImportCalendar struct {
+	client   *slack.Client
+	repo     repo.Repository
+	importer *importer.Importer
+}
+
+func NewImportCalendar(client *slack.Client, repo repo.Repository, importer *importer.Importer) *ImportCalendar {
+	return &ImportCalendar{client, repo, importer}
+}
+
+func (c *ImportCalendar) Route() string {
+	return "import"
+}
+
+func (c *ImportCalendar) Name() string {
+	return "Import calendar"
+}
+
+func (c *ImportCalendar) Description() string {
+	return "Imports your absences from your calendar"
+}
+
+func (c *ImportCalendar) Handle(conv *Conversation) {
+	if !isDirectMessage(conv.Message().Channel) {
+		conv.Say("import_public")
+		return
+	}
+
+	address, err := conv.Param("url")
+	if nil != err || len(address) == 0 {
+		conv.Say("not_understood")
+		return
+	}
+
+	slackUser, err := c.client.GetUserInfo(conv.Message().UserID)
+	if nil != err {
+		log.Error(err)
+		conv.Say("not_understood")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), importTimeout)
+	defer cancel()
+
+	events, err := importer.FetchICS(ctx, slackLink(address))
+	if nil != err {
+		log.WithError(err).Debug("Could not fetch a calendar")
+		conv.Say("import_failed")
+		return
+	}
+
+	result, err := importEvents(ctx, c.repo, c.importer, slackUser, slackLink(address), events, time.Now(), time.Time{})
+	if nil != err {
+		conv.SayError(err)
+		return
+	}
+
+	conv.Say("import_done", result.Added, result.Updated, result.Cancelled)
+}
+
+// importEvents imports the events fetched from the source between now and to
+// into the statuses of the user, creating the user the first time
+func importEvents(ctx context.Context, r repo.Repository, i *importer.Importer, slackUser *slack.User, source string, events []*importer.Event, now time.Time, to time.Time) (importer.Result, error) {
+	var result importer.Result
+	err := updateOrCreate(ctx, r, slackUser, func(user *model.User) error {
+		if len(user.Location) == 0 {
+			user.Location = slackUser.TZ
+		}
+
+		result = i.Apply(user, source, events, now, to)
+		if result == (importer.Result{}) {
+			return errNothingImported
+		}
+
+		return nil
+	})
+
+	if err == errNothingImported {
+		return result, nil
+	}
+
+	return result, err
+}
+
+// slackLink takes the address out of a link as slack formats it, such as
+// <https://example.com/a.ics> or <https://example.com/a.ics|example.com/a.ics>
+func slackLink(text string) string {
+	text = strings.TrimSuffix(strings.TrimPrefix(text, "<"), ">")
+	if i := strings.Index(text, "|"); i >= 0 {
+		text = text[:i]
+	}
+
+	return text
+}
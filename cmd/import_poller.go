@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"context"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/italolelis/hellowork/importer"
+	"github.com/italolelis/hellowork/model"
+	"github.com/italolelis/hellowork/repo"
+	"github.com/nlopes/slack"
+)
+
+// importWindow is how far ahead the calendars are looked at
+const importWindow = 365 * 24 * time.Hour
+
+// CalendarPoller imports the absences booked in CalDAV calendars, such as a
+// shared team calendar. Every event goes to the slack user with the email of
+// its organizer, events without one are left out
+type CalendarPoller struct {
+	client    *slack.Client
+	repo      repo.Repository
+	importer  *importer.Importer
+	calendars []*importer.CalDAV
+	interval  time.Duration
+}
+
+func NewCalendarPoller(client *slack.Client, repo repo.Repository, importer *importer.Importer, calendars []*importer.CalDAV, interval time.Duration) *CalendarPoller {
+	return &CalendarPoller{client, repo, importer, calendars, interval}
+}
+
+// Run polls the calendars right away and then at every interval
+func (p *CalendarPoller) Run() {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		for _, calendar := range p.calendars {
+			p.Poll(calendar, time.Now())
+		}
+
+		<-ticker.C
+	}
+}
+
+// Poll imports the events of the calendar that aren't over at now, and
+// cancels what was imported from the ones deleted since
+func (p *CalendarPoller) Poll(calendar *importer.CalDAV, now time.Time) {
+	logger := log.WithField("calendar", calendar.String())
+	ctx, cancel := context.WithTimeout(context.Background(), importTimeout)
+	defer cancel()
+
+	to := now.Add(importWindow)
+	events, err := calendar.Events(ctx, now, to)
+	if nil != err {
+		logger.WithError(err).Warn("Could not poll the calendar")
+		return
+	}
+
+	byOrganizer := make(map[string][]*importer.Event)
+	for _, event := range events {
+		if len(event.Organizer) > 0 {
+			byOrganizer[event.Organizer] = append(byOrganizer[event.Organizer], event)
+		}
+	}
+
+	organizers := make(map[model.UserID]bool)
+	for email, events := range byOrganizer {
+		slackUser, err := p.client.GetUserByEmail(email)
+		if nil != err {
+			logger.WithError(err).WithField("email", email).Debug("No slack user for the organizer")
+			continue
+		}
+
+		organizers[model.UserID(slackUser.ID)] = true
+		result, err := importEvents(ctx, p.repo, p.importer, slackUser, calendar.String(), events, now, to)
+		if nil != err {
+			logger.WithError(err).WithField("user", slackUser.ID).Warn("Could not import the events")
+			continue
+		}
+
+		logger.WithField("user", slackUser.ID).Debugf("Imported %+v", result)
+	}
+
+	p.cancelDeleted(ctx, calendar.String(), now, to, organizers)
+}
+
+// cancelDeleted cancels what was imported from the calendar for the users
+// out in the window who aren't among the organizers anymore, since all of
+// their events were deleted
+func (p *CalendarPoller) cancelDeleted(ctx context.Context, source string, now time.Time, to time.Time, organizers map[model.UserID]bool) {
+	logger := log.WithField("calendar", source)
+	users, err := p.repo.FindAllOutBetween(ctx, now, to)
+	if nil != err {
+		logger.WithError(err).Warn("Could not look for deleted events")
+		return
+	}
+
+	for _, user := range users {
+		if organizers[user.ID] || !hasImported(user, source) {
+			continue
+		}
+
+		var result importer.Result
+		err := repo.Update(ctx, p.repo, string(user.ID), func(user *model.User) error {
+			result = p.importer.Apply(user, source, nil, now, to)
+			if result == (importer.Result{}) {
+				return errNothingImported
+			}
+
+			return nil
+		})
+
+		if nil != err && err != errNothingImported {
+			logger.WithError(err).WithField("user", user.ID).Warn("Could not cancel the deleted events")
+			continue
+		}
+
+		logger.WithField("user", user.ID).Debugf("Imported %+v", result)
+	}
+}
+
+// hasImported reports whether the user has statuses imported from the source
+func hasImported(user *model.User, source string) bool {
+	for _, status := range user.Statuses {
+		if importer.IsImportedFrom(status, source) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/italolelis/hellowork/importer"
+	"github.com/italolelis/hellowork/model"
+	"github.com/italolelis/hellowork/repo"
+)
+
+func TestCalendarPollerCancelsDeletedEvents(t *testing.T) {
+	const source = "https://dav.example.com/calendars/team/absences/"
+	ctx := context.Background()
+	now := time.Date(2030, time.March, 1, 9, 0, 0, 0, time.UTC)
+	r := repo.NewInMemory()
+
+	// U1 still organizes events, U2's were all deleted and U3 told
+	// hellowork about the vacation
+	for _, id := range []model.UserID{"U1", "U2", "U3"} {
+		user := model.NewUser(id)
+		status := model.NewStatus("", time.Date(2030, time.March, 4, 0, 0, 0, 0, time.UTC), time.Date(2030, time.March, 8, 23, 59, 59, 999999999, time.UTC), model.Vacation)
+		if id != "U3" {
+			status.ID = importer.StatusID(source, "vacation-"+string(id))
+		}
+
+		user.AddStatus(status, id)
+		if err := r.Add(ctx, user); nil != err {
+			t.Fatal(err)
+		}
+	}
+
+	p := NewCalendarPoller(nil, r, importer.NewImporter(nil), nil, time.Minute)
+	p.cancelDeleted(ctx, source, now, now.Add(importWindow), map[model.UserID]bool{"U1": true})
+
+	for id, statuses := range map[string]int{"U1": 1, "U2": 0, "U3": 1} {
+		user, err := r.Find(ctx, id)
+		if nil != err {
+			t.Fatal(err)
+		}
+
+		if len(user.Statuses) != statuses {
+			t.Errorf("%s has %d statuses, want %d", id, len(user.Statuses), statuses)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/italolelis/hellowork/repo"
+)
+
+// ListStatuses shows your upcoming statuses along with the IDs the other
+// commands accept
+type ListStatuses struct {
+	repo repo.Repository
+}
+
+func NewListStatuses(repo repo.Repository) *ListStatuses {
+	return &ListStatuses{repo}
+}
+
+func (c *ListStatuses) Route() string {
+	return "list"
+}
+
+func (c *ListStatuses) Name() string {
+	return "List statuses"
+}
+
+func (c *ListStatuses) Description() string {
+	return "Lists your upcoming statuses"
+}
+
+func (c *ListStatuses) Handle(conv *Conversation) {
+	ctx, cancel := newContext()
+	defer cancel()
+
+	user, err := c.repo.Find(ctx, conv.Message().UserID)
+	switch {
+	case err == repo.ErrNotFound:
+		conv.Say("statuses_empty")
+		return
+	case nil != err:
+		conv.SayError(err)
+		return
+	}
+
+	upcoming := user.Upcoming(user.Now())
+	if len(upcoming) == 0 {
+		conv.Say("statuses_empty")
+		return
+	}
+
+	loc := user.TimeLocation()
+	lines := make([]string, len(upcoming))
+	for i, status := range upcoming {
+		lines[i] = conv.Catalogue.T("statuses_line", shortID(status), status.Reason, conv.Catalogue.Date(status.From.In(loc)), formatEnd(conv.Catalogue, status, loc))
+	}
+
+	conv.Reply(conv.Catalogue.T("statuses") + strings.Join(lines, "\n"))
+}
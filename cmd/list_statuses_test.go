@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/italolelis/hellowork/i18n"
+	"github.com/italolelis/hellowork/model"
+)
+
+func TestListStatuses(t *testing.T) {
+	en := i18n.Get(i18n.English)
+	r, _, _ := newStatusesRepo(t)
+	cases := []struct {
+		user  string
+		reply string
+	}{
+		{"U1", en.T("statuses") +
+			en.T("statuses_line", "aaaaaa", model.Vacation, "03/03/2031", "07/03/2031") + "\n" +
+			en.T("statuses_line", "bbbbbb", model.Sick, "01/04/2031", en.T("open_end"))},
+		{"U9", en.T("statuses_empty")},
+	}
+
+	for _, c := range cases {
+		conv, fake := newTestConversation(t, i18n.English, "list", c.user, "D1", "show my statuses")
+		NewListStatuses(r).Handle(conv)
+
+		if want := []string{c.reply}; !reflect.DeepEqual(fake.replies, want) {
+			t.Errorf("%s: replied %q, want %q", c.user, fake.replies, want)
+		}
+	}
+}
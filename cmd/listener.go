@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"github.com/nlopes/slack"
+)
+
+// MessageHandler gets every message the bot can see, not only the commands
+// hanu hands over
+type MessageHandler interface {
+	HandleMessage(msg *slack.MessageEvent, botID string)
+}
+
+// Listen opens an RTM connection of its own and hands every message written
+// by a person to the handlers, until the connection is closed
+func Listen(client *slack.Client, handlers ...MessageHandler) {
+	rtm := client.NewRTM()
+	go rtm.ManageConnection()
+
+	var botID string
+	for event := range rtm.IncomingEvents {
+		switch ev := event.Data.(type) {
+		case *slack.ConnectedEvent:
+			botID = ev.Info.User.ID
+		case *slack.MessageEvent:
+			if len(ev.SubType) > 0 || len(ev.BotID) > 0 || ev.User == botID {
+				continue
+			}
+
+			for _, handler := range handlers {
+				handler.HandleMessage(ev, botID)
+			}
+		}
+	}
+}
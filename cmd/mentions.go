@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"regexp"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/italolelis/hellowork/i18n"
+	"github.com/italolelis/hellowork/model"
+	"github.com/italolelis/hellowork/repo"
+	"github.com/nlopes/slack"
+)
+
+// mentionCooldown is how long a channel isn't told again about the same
+// absence
+const mentionCooldown = 4 * time.Hour
+
+var mentionPattern = regexp.MustCompile(`<@([a-zA-Z0-9]+)(\|[^>]*)?>`)
+
+// Mentions watches every message the bot can see and answers in a thread
+// when it mentions somebody who is out. It isn't a command, hanu only hands
+// over the messages addressed to the bot
+type Mentions struct {
+	repo      repo.Repository
+	notifier  Notifier
+	catalogue *i18n.Catalogue
+	now       func() time.Time
+
+	mu sync.Mutex
+	// told maps a channel and a status to when the channel was last told
+	// about it
+	told map[string]time.Time
+}
+
+func NewMentions(repo repo.Repository, notifier Notifier, catalogue *i18n.Catalogue) *Mentions {
+	return &Mentions{repo: repo, notifier: notifier, catalogue: catalogue, now: time.Now, told: make(map[string]time.Time)}
+}
+
+// HandleMessage tells the channel about the mentioned users who are out.
+// Messages from bots, edits and the ones addressed to hellowork itself are
+// left alone
+func (m *Mentions) HandleMessage(msg *slack.MessageEvent, botID string) {
+	if isDirectMessage(msg.Channel) {
+		return
+	}
+
+	ids := m.mentioned(msg, botID)
+	if len(ids) == 0 {
+		return
+	}
+
+	ctx, cancel := newContext()
+	defer cancel()
+
+	now := m.now()
+	users, err := m.repo.FindAllByID(ctx, ids, now)
+	if nil != err {
+		log.WithError(err).Warn("Could not look up the mentioned users")
+		return
+	}
+
+	thread := msg.ThreadTimestamp
+	if len(thread) == 0 {
+		thread = msg.Timestamp
+	}
+
+	for _, user := range users {
+		status := user.StatusAt(now)
+		if nil == status || !m.shouldTell(msg.Channel, status, now) {
+			continue
+		}
+
+		if err := m.notifier.NotifyThread(msg.Channel, thread, m.describe(user, status)); nil != err {
+			log.WithError(err).WithField("channel", msg.Channel).Warn("Could not tell the channel about the absence")
+		}
+	}
+}
+
+// mentioned returns the users mentioned in the message, each one once,
+// leaving out the sender. A message mentioning the bot is a command
+func (m *Mentions) mentioned(msg *slack.MessageEvent, botID string) []string {
+	var ids []string
+	seen := make(map[string]bool)
+
+	for _, match := range mentionPattern.FindAllStringSubmatch(msg.Text, -1) {
+		id := match[1]
+		if id == botID {
+			return nil
+		}
+
+		if id != msg.User && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	return ids
+}
+
+// shouldTell reports whether the channel wasn't told about the status
+// within the cooldown, remembering it was told now
+func (m *Mentions) shouldTell(channel string, status *model.Status, now time.Time) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key, at := range m.told {
+		if now.Sub(at) >= mentionCooldown {
+			delete(m.told, key)
+		}
+	}
+
+	key := channel + "/" + string(status.ID)
+	if _, exists := m.told[key]; exists {
+		return false
+	}
+
+	m.told[key] = now
+	return true
+}
+
+func (m *Mentions) describe(user *model.User, status *model.Status) string {
+	to := status.To.In(user.TimeLocation())
+	return m.catalogue.T("mention_out", user.ID, status.Reason, m.catalogue.Weekday(to), m.catalogue.Date(to))
+}
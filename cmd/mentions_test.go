@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/italolelis/hellowork/i18n"
+	"github.com/italolelis/hellowork/model"
+	"github.com/italolelis/hellowork/repo"
+	"github.com/nlopes/slack"
+)
+
+func TestMentioned(t *testing.T) {
+	m := NewMentions(nil, nil, nil)
+	cases := []struct {
+		text string
+		want []string
+	}{
+		{"hey <@U2>, is <@U3|bob> around?", []string{"U2", "U3"}},
+		{"<@U2> <@U2> <@U2|wally>", []string{"U2"}},
+		{"as I told <@U1> myself", nil},
+		{"<@U2> where is <@B>", nil},
+		{"no mentions here, just an email@example.com", nil},
+		{"<#C1|general> and <!here>", nil},
+	}
+
+	for _, c := range cases {
+		got := m.mentioned(&slack.MessageEvent{Msg: slack.Msg{User: "U1", Text: c.text}}, "B")
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%q: got %v, want %v", c.text, got, c.want)
+		}
+	}
+}
+
+func TestMentions(t *testing.T) {
+	ctx := context.Background()
+	en := i18n.Get(i18n.English)
+	start := time.Date(2030, time.March, 5, 10, 0, 0, 0, time.UTC)
+
+	r := repo.NewInMemory()
+	out := model.NewUser("U2")
+	out.AddStatus(model.NewStatus("", time.Date(2030, time.March, 4, 0, 0, 0, 0, time.UTC), time.Date(2030, time.March, 8, 23, 59, 59, 999999999, time.UTC), model.Vacation), "U2")
+	later := model.NewUser("U3")
+	later.AddStatus(model.NewStatus("", time.Date(2030, time.April, 1, 0, 0, 0, 0, time.UTC), time.Date(2030, time.April, 3, 23, 59, 59, 999999999, time.UTC), model.Sick), "U3")
+	for _, user := range []*model.User{out, later} {
+		if err := r.Add(ctx, user); nil != err {
+			t.Fatal(err)
+		}
+	}
+
+	told := "/1.0: " + en.T("mention_out", "U2", model.Vacation, "Friday", "08/03/2030")
+
+	// the messages follow each other with the same mentions, after is the
+	// time since the previous one
+	cases := []struct {
+		name    string
+		after   time.Duration
+		channel string
+		user    string
+		thread  string
+		text    string
+		sent    []string
+	}{
+		{"out, later and unknown", 0, "C1", "U1", "", "<@U2> <@U3> <@U4> standup?", []string{"C1" + told}},
+		{"right after", time.Minute, "C1", "U1", "", "<@U2>?", nil},
+		{"another channel", 0, "C2", "U1", "", "<@U2>?", []string{"C2" + told}},
+		{"within the cooldown", mentionCooldown - 2*time.Minute, "C1", "U5", "", "<@U2>?", nil},
+		{"after the cooldown", time.Minute, "C1", "U1", "", "<@U2>?", []string{"C1" + told}},
+		{"direct message", mentionCooldown, "D1", "U1", "", "<@U2>?", nil},
+		{"from the absent user", 0, "C3", "U2", "", "as <@U2> I'm out", nil},
+		{"addressed to the bot", 0, "C3", "U1", "", "<@B> where is <@U2>", nil},
+		{"in a thread", 0, "C3", "U1", "0.5", "<@U2>?", []string{"C3/0.5: " + en.T("mention_out", "U2", model.Vacation, "Friday", "08/03/2030")}},
+	}
+
+	notifier := &fakeNotifier{}
+	m := NewMentions(r, notifier, en)
+	now := start
+	m.now = func() time.Time { return now }
+
+	for _, c := range cases {
+		notifier.sent = nil
+		now = now.Add(c.after)
+		m.HandleMessage(&slack.MessageEvent{Msg: slack.Msg{
+			Channel:         c.channel,
+			User:            c.user,
+			Text:            c.text,
+			Timestamp:       "1.0",
+			ThreadTimestamp: c.thread,
+		}}, "B")
+
+		if !reflect.DeepEqual(notifier.sent, c.sent) {
+			t.Errorf("%s: sent %q, want %q", c.name, notifier.sent, c.sent)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"strings"
+	"time"
+
+	"github.com/italolelis/hellowork/model"
+	"github.com/italolelis/hellowork/repo"
+	htime "github.com/italolelis/hellowork/time"
+)
+
+// Move shifts the start or the end of one of your upcoming statuses, as in
+// "move my vacation to end on friday"
+type Move struct {
+	repo repo.Repository
+}
+
+func NewMove(repo repo.Repository) *Move {
+	return &Move{repo}
+}
+
+func (c *Move) Route() string {
+	return "move"
+}
+
+func (c *Move) Name() string {
+	return "Move status"
+}
+
+func (c *Move) Description() string {
+	return "Moves the start or the end of one of your statuses"
+}
+
+func (c *Move) Handle(conv *Conversation) {
+	statusParam, err := conv.Param("status")
+	if nil != err {
+		conv.Say("not_understood")
+		return
+	}
+
+	edgeParam, err := conv.Param("edge")
+	if nil != err {
+		conv.Say("not_understood")
+		return
+	}
+
+	whenParam, err := conv.Param("when")
+	if nil != err {
+		conv.Say("not_understood")
+		return
+	}
+
+	ctx, cancel := newContext()
+	defer cancel()
+
+	id := conv.Message().UserID
+	var (
+		moved    model.Status
+		loc      *time.Location
+		parseErr error
+	)
+
+	err = repo.Update(ctx, c.repo, id, func(user *model.User) error {
+		status := matchStatus(user, statusParam, conv.Catalogue)
+		if nil == status {
+			return model.ErrStatusNotFound
+		}
+
+		date, err := model.ParseTime(conv.Catalogue.Normalize(whenParam), user.Location)
+		if nil != err {
+			parseErr = err
+			return err
+		}
+
+		moved = *status
+		if strings.EqualFold(conv.Catalogue.Normalize(edgeParam), "start") {
+			moved.From = htime.StartOfDay(date)
+		} else {
+			moved.To = htime.EndOfDay(date)
+		}
+
+		if !moved.To.IsZero() && moved.To.Before(moved.From) {
+			return model.ErrInvalidPeriod
+		}
+
+		loc = user.TimeLocation()
+		return user.EditStatus(&moved, model.UserID(id))
+	})
+
+	switch {
+	case err == repo.ErrNotFound || err == model.ErrStatusNotFound:
+		conv.Say("status_not_found", statusParam)
+	case err == model.ErrInvalidPeriod:
+		conv.Say("status_invalid_period")
+	case nil != err && err == parseErr:
+		sayParseError(conv, err)
+	case nil != err:
+		conv.SayError(err)
+	default:
+		conv.Say("status_moved", moved.Reason, conv.Catalogue.Date(moved.From.In(loc)), formatEnd(conv.Catalogue, &moved, loc))
+	}
+}
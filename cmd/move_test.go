@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/italolelis/hellowork/i18n"
+	"github.com/italolelis/hellowork/model"
+)
+
+func TestMove(t *testing.T) {
+	en := i18n.Get(i18n.English)
+	cases := []struct {
+		user  string
+		text  string
+		reply string
+		// from and to are the days of the vacation afterwards
+		from string
+		to   string
+	}{
+		{"U1", "move my vacation to end 11/3/2031", en.T("status_moved", model.Vacation, "03/03/2031", "11/03/2031"), "2031-03-03 00:00", "2031-03-11 23:59"},
+		{"U1", "move status aaaaaa to start 5/3/2031", en.T("status_moved", model.Vacation, "05/03/2031", "07/03/2031"), "2031-03-05 00:00", "2031-03-07 23:59"},
+		{"U1", "move my vacation to start 10/3/2031", en.T("status_invalid_period"), "2031-03-03 00:00", "2031-03-07 23:59"},
+		{"U1", "move my vacation to end whenever", en.T("not_understood_word", "whenever"), "2031-03-03 00:00", "2031-03-07 23:59"},
+		{"U1", "move my vacation to end 1/1/2300", en.T("date_too_far"), "2031-03-03 00:00", "2031-03-07 23:59"},
+		{"U1", "move my remote to end 11/3/2031", en.T("status_not_found", "remote"), "2031-03-03 00:00", "2031-03-07 23:59"},
+		{"U9", "move my vacation to end 11/3/2031", en.T("status_not_found", "vacation"), "2031-03-03 00:00", "2031-03-07 23:59"},
+	}
+
+	for _, c := range cases {
+		r, _, _ := newStatusesRepo(t)
+		conv, fake := newTestConversation(t, i18n.English, "move", c.user, "D1", c.text)
+		NewMove(r).Handle(conv)
+
+		if want := []string{c.reply}; !reflect.DeepEqual(fake.replies, want) {
+			t.Errorf("%q: replied %q, want %q", c.text, fake.replies, want)
+		}
+
+		user, err := r.Find(context.Background(), "U1")
+		if nil != err {
+			t.Fatal(err)
+		}
+
+		vacation := user.FindStatus("aaaaaa11")
+		loc := user.TimeLocation()
+		if from, to := vacation.From.In(loc).Format("2006-01-02 15:04"), vacation.To.In(loc).Format("2006-01-02 15:04"); from != c.from || to != c.to {
+			t.Errorf("%q: the vacation is from %s until %s, want %s until %s", c.text, from, to, c.from, c.to)
+		}
+
+		if !reflect.DeepEqual(vacation.Channels, []string{"C1"}) {
+			t.Errorf("%q: the vacation was announced in %v", c.text, vacation.Channels)
+		}
+	}
+}
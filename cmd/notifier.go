@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/nlopes/slack"
+)
+
+// Notifier posts a message to a channel outside of the conversation that
+// triggered it
+type Notifier interface {
+	Notify(channel string, text string) error
+	// NotifyThread answers in the thread of the given message timestamp
+	NotifyThread(channel string, thread string, text string) error
+}
+
+// SlackNotifier posts as the bot through the web API
+type SlackNotifier struct {
+	client *slack.Client
+}
+
+func NewSlackNotifier(client *slack.Client) *SlackNotifier {
+	return &SlackNotifier{client}
+}
+
+func (n *SlackNotifier) Notify(channel string, text string) error {
+	_, _, err := n.client.PostMessage(channel, slack.MsgOptionText(text, false), slack.MsgOptionAsUser(true))
+	return err
+}
+
+func (n *SlackNotifier) NotifyThread(channel string, thread string, text string) error {
+	_, _, err := n.client.PostMessage(channel, slack.MsgOptionText(text, false), slack.MsgOptionAsUser(true), slack.MsgOptionTS(thread))
+	return err
+}
+
+// isDirectMessage tells whether the channel is a direct message with the
+// bot, which nobody else reads
+func isDirectMessage(channel string) bool {
+	return strings.HasPrefix(channel, "D")
+}
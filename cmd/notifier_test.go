@@ -0,0 +1,26 @@
+package cmd
+
+import "sync"
+
+// fakeNotifier records the messages instead of posting them, as
+// "channel: text" or "channel/thread: text"
+type fakeNotifier struct {
+	mu   sync.Mutex
+	sent []string
+}
+
+func (n *fakeNotifier) Notify(channel string, text string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.sent = append(n.sent, channel+": "+text)
+	return nil
+}
+
+func (n *fakeNotifier) NotifyThread(channel string, thread string, text string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.sent = append(n.sent, channel+"/"+thread+": "+text)
+	return nil
+}
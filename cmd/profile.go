@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/italolelis/hellowork/model"
+	"github.com/italolelis/hellowork/repo"
+	"github.com/nlopes/slack"
+)
+
+// Profiles reads and writes the custom status of slack profiles, with the
+// token of their owner since bots can't change somebody else's profile
+type Profiles interface {
+	// Owner returns the ID of the user the token belongs to
+	Owner(token string) (string, error)
+	Status(token string, user string) (text string, emoji string, err error)
+	// SetStatus changes the status until the given time, or for good when
+	// it's zero
+	SetStatus(token string, text string, emoji string, until time.Time) error
+}
+
+// SlackProfiles talks to the web API with the user tokens
+type SlackProfiles struct{}
+
+func NewSlackProfiles() *SlackProfiles {
+	return &SlackProfiles{}
+}
+
+func (p *SlackProfiles) Owner(token string) (string, error) {
+	auth, err := slack.New(token).AuthTest()
+	if nil != err {
+		return "", err
+	}
+
+	return auth.UserID, nil
+}
+
+func (p *SlackProfiles) Status(token string, user string) (string, string, error) {
+	profile, err := slack.New(token).GetUserProfile(user, false)
+	if nil != err {
+		return "", "", err
+	}
+
+	return profile.StatusText, profile.StatusEmoji, nil
+}
+
+func (p *SlackProfiles) SetStatus(token string, text string, emoji string, until time.Time) error {
+	var expiration int64
+	if !until.IsZero() {
+		expiration = until.Unix()
+	}
+
+	return slack.New(token).SetUserCustomStatus(text, emoji, expiration)
+}
+
+// SyncProfile takes the token hellowork needs to show your statuses on your
+// slack profile. Tokens are only accepted in direct messages
+type SyncProfile struct {
+	client   *slack.Client
+	repo     repo.Repository
+	profiles Profiles
+}
+
+func NewSyncProfile(client *slack.Client, repo repo.Repository, profiles Profiles) *SyncProfile {
+	return &SyncProfile{client, repo, profiles}
+}
+
+func (c *SyncProfile) Route() string {
+	return "sync_profile"
+}
+
+func (c *SyncProfile) Name() string {
+	return "Sync profile"
+}
+
+func (c *SyncProfile) Description() string {
+	return "Shows your statuses on your slack profile"
+}
+
+func (c *SyncProfile) Handle(conv *Conversation) {
+	if !isDirectMessage(conv.Message().Channel) {
+		conv.Say("profile_token_public")
+		return
+	}
+
+	token, err := conv.Param("token")
+	if nil != err || len(token) == 0 {
+		conv.Say("not_understood")
+		return
+	}
+
+	id := conv.Message().UserID
+	if owner, err := c.profiles.Owner(token); nil != err || owner != id {
+		log.WithError(err).Debug("Refused a profile token")
+		conv.Say("profile_token_invalid")
+		return
+	}
+
+	slackUser, err := c.client.GetUserInfo(id)
+	if nil != err {
+		log.Error(err)
+		conv.Say("not_understood")
+		return
+	}
+
+	ctx, cancel := newContext()
+	defer cancel()
+
+	err = updateOrCreate(ctx, c.repo, slackUser, func(user *model.User) error {
+		if nil == user.Profile {
+			user.Profile = &model.ProfileSync{}
+		}
+
+		user.Profile.Token = token
+		return nil
+	})
+	if nil != err {
+		conv.SayError(err)
+		return
+	}
+
+	conv.Say("profile_synced")
+}
+
+// StopProfileSync forgets your token, putting back what your profile showed
+// before if hellowork changed it
+type StopProfileSync struct {
+	repo     repo.Repository
+	profiles Profiles
+}
+
+func NewStopProfileSync(repo repo.Repository, profiles Profiles) *StopProfileSync {
+	return &StopProfileSync{repo, profiles}
+}
+
+func (c *StopProfileSync) Route() string {
+	return "stop_profile_sync"
+}
+
+func (c *StopProfileSync) Name() string {
+	return "Stop profile sync"
+}
+
+func (c *StopProfileSync) Description() string {
+	return "Stops showing your statuses on your slack profile"
+}
+
+func (c *StopProfileSync) Handle(conv *Conversation) {
+	ctx, cancel := newContext()
+	defer cancel()
+
+	var profile *model.ProfileSync
+	err := repo.Update(ctx, c.repo, conv.Message().UserID, func(user *model.User) error {
+		profile = user.Profile
+		user.Profile = nil
+		return nil
+	})
+
+	switch {
+	case err == repo.ErrNotFound || (nil == err && nil == profile):
+		conv.Say("profile_not_synced")
+		return
+	case nil != err:
+		conv.SayError(err)
+		return
+	}
+
+	if len(profile.Status) > 0 {
+		if err := c.profiles.SetStatus(profile.Token, profile.Text, profile.Emoji, time.Time{}); nil != err {
+			log.WithError(err).Warn("Could not put the slack profile back")
+		}
+	}
+
+	conv.Say("profile_sync_stopped")
+}
@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/italolelis/hellowork/i18n"
+	"github.com/italolelis/hellowork/model"
+	"github.com/italolelis/hellowork/repo"
+)
+
+// profileSyncFallback is the longest the syncer waits between two syncs,
+// to retry the profiles it couldn't change and to catch statuses it wasn't
+// told about
+const profileSyncFallback = time.Hour
+
+var profileEmojis = map[model.Reason]string{
+	model.Vacation:    ":palm_tree:",
+	model.Sick:        ":face_with_thermometer:",
+	model.WorkTrip:    ":airplane:",
+	model.Remote:      ":house:",
+	model.OutOfOffice: ":no_entry:",
+}
+
+// ProfileSyncer shows the status each user is on in their slack profile,
+// for the users who gave their token, and puts back what the profile showed
+// before once the status is over
+type ProfileSyncer struct {
+	repo      repo.Repository
+	profiles  Profiles
+	catalogue *i18n.Catalogue
+	now       func() time.Time
+	wake      chan struct{}
+}
+
+func NewProfileSyncer(repo repo.Repository, profiles Profiles, catalogue *i18n.Catalogue) *ProfileSyncer {
+	return &ProfileSyncer{repo, profiles, catalogue, time.Now, make(chan struct{}, 1)}
+}
+
+// Run syncs the profiles right away, then whenever a status of a synced
+// user starts or ends and whenever it's woken up
+func (s *ProfileSyncer) Run() {
+	for {
+		timer := time.NewTimer(s.Sync().Sub(s.now()))
+		select {
+		case <-timer.C:
+		case <-s.wake:
+			timer.Stop()
+		}
+	}
+}
+
+// Wake has the profiles synced again, for when a status was saved. It
+// never blocks
+func (s *ProfileSyncer) Wake() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Sync brings every synced profile up to date with the statuses and returns
+// when it has to be done next
+func (s *ProfileSyncer) Sync() time.Time {
+	now := s.now()
+	next := now.Add(profileSyncFallback)
+
+	ctx, cancel := newContext()
+	defer cancel()
+
+	users, err := s.repo.FindAll(ctx)
+	if nil != err {
+		log.WithError(err).Warn("Could not load the users to sync their profiles")
+		return next
+	}
+
+	for _, user := range users {
+		if nil == user.Profile {
+			continue
+		}
+
+		next = nextBoundary(user, now, next)
+		status := user.StatusAt(now)
+		if user.Profile.IsShowing(status) {
+			continue
+		}
+
+		if err := s.sync(user, status); nil != err {
+			log.WithError(err).WithField("user", user.ID).Warn("Could not sync the slack profile")
+		}
+	}
+
+	return next
+}
+
+// nextBoundary returns when the next status of the user starts or ends
+// after now, if that's before next. A status covers its last instant, so
+// the profile changes right after it
+func nextBoundary(user *model.User, now time.Time, next time.Time) time.Time {
+	for _, status := range user.Statuses {
+		if status.To.IsZero() {
+			continue
+		}
+
+		for _, t := range []time.Time{status.From, status.To.Add(time.Nanosecond)} {
+			if t.After(now) && t.Before(next) {
+				next = t
+			}
+		}
+	}
+
+	return next
+}
+
+// sync shows the status on the profile of the user, or puts back what was
+// there before when the user is on none
+func (s *ProfileSyncer) sync(user *model.User, status *model.Status) error {
+	profile := *user.Profile
+	if nil == status {
+		if err := s.profiles.SetStatus(profile.Token, profile.Text, profile.Emoji, time.Time{}); nil != err {
+			return err
+		}
+
+		profile = model.ProfileSync{Token: profile.Token}
+	} else {
+		// what the user had before is only there the first time, later on
+		// it's one of ours
+		if len(profile.Status) == 0 {
+			text, emoji, err := s.profiles.Status(profile.Token, string(user.ID))
+			if nil != err {
+				return err
+			}
+
+			profile.Text, profile.Emoji = text, emoji
+		}
+
+		to := status.To.In(user.TimeLocation())
+		text := s.catalogue.T("profile_status", status.Reason, s.catalogue.Weekday(to), s.catalogue.Date(to))
+		if err := s.profiles.SetStatus(profile.Token, text, profileEmojis[status.Reason], status.To); nil != err {
+			return err
+		}
+
+		profile.Status, profile.Until = status.ID, status.To
+	}
+
+	ctx, cancel := newContext()
+	defer cancel()
+
+	return repo.Update(ctx, s.repo, string(user.ID), func(user *model.User) error {
+		// the user may have stopped the sync or given another token meanwhile
+		if nil == user.Profile {
+			return nil
+		}
+
+		profile.Token = user.Profile.Token
+		user.Profile = &profile
+		return nil
+	})
+}
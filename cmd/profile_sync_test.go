@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/italolelis/hellowork/i18n"
+	"github.com/italolelis/hellowork/model"
+	"github.com/italolelis/hellowork/repo"
+)
+
+// fakeProfiles keeps the custom status of each token and records every
+// change, tokens starting with "broken" fail
+type fakeProfiles struct {
+	statuses map[string][2]string
+	changes  []string
+}
+
+func (p *fakeProfiles) Owner(token string) (string, error) {
+	return "U1", nil
+}
+
+func (p *fakeProfiles) Status(token string, user string) (string, string, error) {
+	if token == "broken" {
+		return "", "", errors.New("invalid_auth")
+	}
+
+	status := p.statuses[token]
+	return status[0], status[1], nil
+}
+
+func (p *fakeProfiles) SetStatus(token string, text string, emoji string, until time.Time) error {
+	if token == "broken" {
+		return errors.New("invalid_auth")
+	}
+
+	p.statuses[token] = [2]string{text, emoji}
+	p.changes = append(p.changes, fmt.Sprintf("%s: %s %s %s", token, emoji, text, until.Format(time.RFC3339)))
+	return nil
+}
+
+func TestProfileSyncer(t *testing.T) {
+	ctx := context.Background()
+	r := repo.NewInMemory()
+	endOfDay := func(day int) time.Time {
+		return time.Date(2030, time.March, day, 23, 59, 59, 999999999, time.UTC)
+	}
+
+	sick := model.NewStatus("", time.Date(2030, time.March, 5, 0, 0, 0, 0, time.UTC), endOfDay(5), model.Sick)
+	vacation := model.NewStatus("", time.Date(2030, time.March, 7, 0, 0, 0, 0, time.UTC), endOfDay(8), model.Vacation)
+
+	synced := model.NewUser("U1")
+	synced.Profile = &model.ProfileSync{Token: "t1"}
+	synced.AddStatus(sick, "U1")
+	synced.AddStatus(vacation, "U1")
+
+	// somebody who didn't give a token, somebody with nothing planned and
+	// somebody whose token doesn't work anymore
+	unsynced := model.NewUser("U2")
+	unsynced.AddStatus(model.NewStatus("", sick.From, sick.To, model.Remote), "U2")
+	idle := model.NewUser("U3")
+	idle.Profile = &model.ProfileSync{Token: "t3"}
+	broken := model.NewUser("U4")
+	broken.Profile = &model.ProfileSync{Token: "broken"}
+	broken.AddStatus(model.NewStatus("", sick.From, time.Date(2030, time.March, 6, 11, 59, 59, 999999999, time.UTC), model.WorkTrip), "U4")
+
+	for _, user := range []*model.User{synced, unsynced, idle, broken} {
+		if err := r.Add(ctx, user); nil != err {
+			t.Fatal(err)
+		}
+	}
+
+	profiles := &fakeProfiles{statuses: map[string][2]string{"t1": {"lunch", ":pizza:"}}}
+	s := NewProfileSyncer(r, profiles, i18n.Get(i18n.English))
+	var now time.Time
+	s.now = func() time.Time { return now }
+
+	steps := []struct {
+		name    string
+		now     time.Time
+		changes []string
+		showing model.StatusID
+		next    time.Time
+	}{
+		{
+			name:    "status started",
+			now:     time.Date(2030, time.March, 5, 23, 30, 0, 0, time.UTC),
+			changes: []string{"t1: :face_with_thermometer: sick until Tuesday (05/03/2030) 2030-03-05T23:59:59Z"},
+			showing: sick.ID,
+			next:    time.Date(2030, time.March, 6, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "nothing changed",
+			now:     time.Date(2030, time.March, 5, 23, 45, 0, 0, time.UTC),
+			showing: sick.ID,
+			next:    time.Date(2030, time.March, 6, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "status ended",
+			now:     time.Date(2030, time.March, 6, 0, 0, 0, 0, time.UTC),
+			changes: []string{"t1: :pizza: lunch 0001-01-01T00:00:00Z"},
+			next:    time.Date(2030, time.March, 6, 1, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "end of a status of somebody else",
+			now:  time.Date(2030, time.March, 6, 11, 30, 0, 0, time.UTC),
+			next: time.Date(2030, time.March, 6, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name: "waiting for the next one",
+			now:  time.Date(2030, time.March, 6, 23, 30, 0, 0, time.UTC),
+			next: time.Date(2030, time.March, 7, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:    "next status started",
+			now:     time.Date(2030, time.March, 7, 0, 0, 0, 0, time.UTC),
+			changes: []string{"t1: :palm_tree: vacation until Friday (08/03/2030) 2030-03-08T23:59:59Z"},
+			showing: vacation.ID,
+			next:    time.Date(2030, time.March, 7, 1, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, step := range steps {
+		profiles.changes = nil
+		now = step.now
+		if next := s.Sync(); !next.Equal(step.next) {
+			t.Errorf("%s: next sync at %s, want %s", step.name, next, step.next)
+		}
+
+		if !reflect.DeepEqual(profiles.changes, step.changes) {
+			t.Errorf("%s: changed %q, want %q", step.name, profiles.changes, step.changes)
+		}
+
+		user, err := r.Find(ctx, "U1")
+		if nil != err {
+			t.Fatal(err)
+		}
+
+		want := model.ProfileSync{Token: "t1"}
+		if len(step.showing) > 0 {
+			status := user.FindStatus(step.showing)
+			want = model.ProfileSync{Token: "t1", Status: status.ID, Until: status.To, Text: "lunch", Emoji: ":pizza:"}
+		}
+
+		if !reflect.DeepEqual(*user.Profile, want) {
+			t.Errorf("%s: profile is %+v, want %+v", step.name, *user.Profile, want)
+		}
+	}
+
+	for _, id := range []string{"U2", "U3", "U4"} {
+		user, err := r.Find(ctx, id)
+		if nil != err {
+			t.Fatal(err)
+		}
+
+		if nil != user.Profile && len(user.Profile.Status) > 0 {
+			t.Errorf("%s: the profile of %s shows %s", id, id, user.Profile.Status)
+		}
+	}
+}
+
+func TestProfileSyncerWakeNeverBlocks(t *testing.T) {
+	s := NewProfileSyncer(nil, nil, nil)
+	s.Wake()
+	s.Wake()
+
+	select {
+	case <-s.wake:
+	default:
+		t.Fatal("the syncer wasn't woken up")
+	}
+}
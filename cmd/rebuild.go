@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	log "github.com/Sirupsen/logrus"
+	"github.com/italolelis/hellowork/repo"
+	"github.com/nlopes/slack"
+)
+
+// Rebuild replays the history of a user over its statuses, to repair the
+// statuses saved by a buggy version. Only admins can use it
+type Rebuild struct {
+	client *slack.Client
+	repo   repo.Repository
+}
+
+func NewRebuild(client *slack.Client, repo repo.Repository) *Rebuild {
+	return &Rebuild{client, repo}
+}
+
+func (c *Rebuild) Route() string {
+	return "rebuild"
+}
+
+func (c *Rebuild) Name() string {
+	return "Rebuild"
+}
+
+func (c *Rebuild) Description() string {
+	return "Rebuilds the statuses of an user from their history"
+}
+
+func (c *Rebuild) Handle(conv *Conversation) {
+	userParam, err := NewUserParam(conv)
+	if nil != err || nil == userParam {
+		log.Error(err)
+		conv.Say("not_understood")
+		return
+	}
+
+	asker, err := c.client.GetUserInfo(conv.Message().UserID)
+	if nil != err {
+		log.Error(err)
+		conv.Say("not_understood")
+		return
+	}
+
+	if !asker.IsAdmin && !asker.IsOwner {
+		conv.Say("rebuild_forbidden")
+		return
+	}
+
+	ctx, cancel := newContext()
+	defer cancel()
+
+	err = repo.Rebuild(ctx, c.repo, userParam.GetUserID())
+	switch {
+	case err == repo.ErrNotFound:
+		conv.Say("history_empty", userParam.Param)
+	case nil != err:
+		conv.SayError(err)
+	default:
+		conv.Say("rebuilt", userParam.Param)
+	}
+}
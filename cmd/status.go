@@ -1,33 +1,30 @@
 package cmd
 
 import (
-	"fmt"
+	"context"
+	"strings"
+	"time"
 
 	log "github.com/Sirupsen/logrus"
-	"github.com/italolelis/hanu"
+	"github.com/italolelis/hellowork/i18n"
 	"github.com/italolelis/hellowork/model"
+	"github.com/italolelis/hellowork/model/dateparse"
 	"github.com/italolelis/hellowork/repo"
 	"github.com/nlopes/slack"
 )
 
 type Status struct {
-	client *slack.Client
-	repo   repo.Repository
+	client    *slack.Client
+	repo      repo.Repository
+	followups *Followups
 }
 
-func NewStatus(client *slack.Client, repo repo.Repository) *Status {
-	return &Status{client, repo}
+func NewStatus(client *slack.Client, repo repo.Repository, followups *Followups) *Status {
+	return &Status{client, repo, followups}
 }
 
-func (s *Status) Commands() []string {
-	return []string{
-		`(?i)I'm on <status>(.*?)`,
-		`(?i)I am on <status>(.*?)`,
-		`(?i)I'll be on <status>(.*?)`,
-		`(?i)I will be on <status>(.*?)`,
-		//`(?i)I'm <status>(.*?)`,
-		//`(?i)I am <status>(.*?)`,
-	}
+func (s *Status) Route() string {
+	return "status"
 }
 
 func (s *Status) Name() string {
@@ -38,39 +35,153 @@ func (s *Status) Description() string {
 	return "Creates a status for you"
 }
 
-func (s *Status) Handler(conv hanu.ConversationInterface) {
-	statusParam, err := conv.String("status")
-	timableParam, err := conv.Match(1)
-	timable, err := model.NewTimableMention(timableParam)
+func (s *Status) Handle(conv *Conversation) {
+	slackUser, err := s.client.GetUserInfo(conv.Message().UserID)
+	if nil != err {
+		log.Error(err)
+		conv.Say("not_understood")
+		return
+	}
+
+	statusParam, err := conv.Param("status")
+	if nil != err {
+		conv.Say("not_understood")
+		return
+	}
+
+	timableParam, err := conv.Param("when")
+	if nil != err {
+		conv.Say("not_understood")
+		return
+	}
+
+	timable, err := model.NewTimableMention(conv.Catalogue.Normalize(timableParam), slackUser.TZ)
+	if err == model.ErrInvalidPeriod {
+		conv.Say("status_invalid_period")
+		return
+	}
+
 	if nil == timable || nil != err {
-		conv.Reply("I'm sorry I can't understand you")
+		sayParseError(conv, err)
 		return
 	}
 
 	from := timable.From
 	to := timable.To
 
-	slackUser, err := s.client.GetUserInfo(conv.Message().UserID)
+	ctx, cancel := newContext()
+	defer cancel()
+
+	status := model.NewStatus("", from, to, model.ParseReason(conv.Catalogue.Normalize(statusParam)))
+	if channel := conv.Message().Channel; !isDirectMessage(channel) {
+		status.Channels = []string{channel}
+	}
+
+	err = s.createStatus(ctx, slackUser, status)
 	if nil != err {
-		log.Panic(err)
+		conv.SayError(err)
+		return
 	}
 
-	s.createStatus(slackUser, model.NewStatus("", from, to, model.ParseReason(statusParam)))
 	if timable.HasOnlyFrom() {
-		conv.Reply("Ok and when will you be back?")
+		s.followups.AskUntil(slackUser.ID, conv.Message().Channel, status, conv.Catalogue)
+		conv.Say("ask_until")
+	} else if nil != timable.Duration {
+		duration := conv.Catalogue.FormatDuration(timable.Duration.Amount, timable.Duration.Unit.String(), timable.Duration.BusinessDays)
+		conv.Say("status_created_duration", conv.Catalogue.Date(from), duration, conv.Catalogue.Weekday(to), conv.Catalogue.Date(to))
 	} else {
-		conv.Reply(fmt.Sprintf("Ok you are on vacations from %s until %s. Enjoy!", from.Format("02/01/2006"), to.Format("02/01/2006")))
+		conv.Say("status_created", conv.Catalogue.Date(from), conv.Catalogue.Date(to))
 	}
 }
 
-func (s *Status) createStatus(slackUser *slack.User, status *model.Status) {
-	var user *model.User
-	user = s.repo.Find(slackUser.ID)
-	if nil == user {
-		user = model.NewUser(model.UserID(slackUser.ID))
-		user.Username = slackUser.Name
+// createStatus adds the status to the user, creating the user the first
+// time
+func (s *Status) createStatus(ctx context.Context, slackUser *slack.User, status *model.Status) error {
+	return updateOrCreate(ctx, s.repo, slackUser, func(user *model.User) error {
+		user.Location = slackUser.TZ
+		user.AddStatus(status, model.UserID(slackUser.ID))
+		return nil
+	})
+}
+
+// updateOrCreate changes the user, creating it the first time
+func updateOrCreate(ctx context.Context, r repo.Repository, slackUser *slack.User, change func(*model.User) error) error {
+	err := repo.Update(ctx, r, slackUser.ID, change)
+	if err != repo.ErrNotFound {
+		return err
+	}
+
+	user := model.NewUser(model.UserID(slackUser.ID))
+	user.Username = slackUser.Name
+	if err := change(user); nil != err {
+		return err
+	}
+
+	if err := r.Add(ctx, user); err != repo.ErrConflict {
+		return err
+	}
+
+	// somebody else created the user in the meantime
+	return repo.Update(ctx, r, slackUser.ID, change)
+}
+
+// sayParseError tells the user which word of a date couldn't be understood,
+// or that the date is too far ahead
+func sayParseError(conv *Conversation, err error) {
+	if err == model.ErrTooFarAhead {
+		conv.Say("date_too_far")
+		return
+	}
+
+	if perr, ok := err.(*dateparse.ParseError); ok && len(perr.Token) > 0 {
+		conv.Say("not_understood_word", perr.Token)
+		return
+	}
+
+	conv.Say("not_understood")
+}
+
+// shortIDLength is how much of the status IDs the users see and type
+const shortIDLength = 6
+
+// shortID returns the beginning of the status ID, enough to tell the
+// statuses of a user apart
+func shortID(status *model.Status) string {
+	if len(status.ID) <= shortIDLength {
+		return string(status.ID)
+	}
+
+	return string(status.ID)[:shortIDLength]
+}
+
+// matchStatus finds the upcoming status the words refer to, either by the
+// beginning of its ID as the list command shows it or by its reason
+func matchStatus(user *model.User, words string, catalogue *i18n.Catalogue) *model.Status {
+	words = strings.ToLower(strings.TrimSpace(words))
+	upcoming := user.Upcoming(user.Now())
+	if len(words) >= shortIDLength {
+		for _, status := range upcoming {
+			if strings.HasPrefix(string(status.ID), words) {
+				return status
+			}
+		}
+	}
+
+	reason := model.ParseReason(catalogue.Normalize(words))
+	for _, status := range upcoming {
+		if status.Reason == reason {
+			return status
+		}
+	}
+
+	return nil
+}
+
+// formatEnd renders the end of a status, which may still be unknown
+func formatEnd(catalogue *i18n.Catalogue, status *model.Status, loc *time.Location) string {
+	if status.To.IsZero() {
+		return catalogue.T("open_end")
 	}
 
-	user.AddStatus(status)
-	s.repo.Add(user)
+	return catalogue.Date(status.To.In(loc))
 }
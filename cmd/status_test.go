@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/italolelis/hellowork/i18n"
+	"github.com/italolelis/hellowork/model"
+	"github.com/italolelis/hellowork/repo"
+)
+
+// newStatusesRepo has U1 in Berlin with a vacation in March 2031 announced
+// in C1 and a sick leave from April 2031 without an end, and returns them
+func newStatusesRepo(t *testing.T) (repo.Repository, *model.Status, *model.Status) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	user := model.NewUser("U1")
+	user.Location = "Europe/Berlin"
+	vacation := model.NewStatus("", time.Date(2031, time.March, 3, 0, 0, 0, 0, berlin), time.Date(2031, time.March, 7, 23, 59, 59, 999999999, berlin), model.Vacation)
+	vacation.ID = "aaaaaa11"
+	vacation.Channels = []string{"C1"}
+	sick := model.NewStatus("", time.Date(2031, time.April, 1, 0, 0, 0, 0, berlin), time.Time{}, model.Sick)
+	sick.ID = "bbbbbb22"
+	user.AddStatus(sick, "U1")
+	user.AddStatus(vacation, "U1")
+
+	r := repo.NewInMemory()
+	if err := r.Add(context.Background(), user); nil != err {
+		t.Fatal(err)
+	}
+
+	return r, vacation, sick
+}
+
+func TestMatchStatus(t *testing.T) {
+	r, vacation, sick := newStatusesRepo(t)
+	user, err := r.Find(context.Background(), "U1")
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	// an ended status doesn't match anymore
+	user.AddStatus(model.NewStatus("", time.Date(2018, time.March, 1, 0, 0, 0, 0, time.UTC), time.Date(2018, time.March, 2, 0, 0, 0, 0, time.UTC), model.Remote), "U1")
+
+	cases := []struct {
+		language i18n.Language
+		words    string
+		want     *model.Status
+	}{
+		{i18n.English, "vacation", vacation},
+		{i18n.English, " Sick ", sick},
+		{i18n.English, "aaaaaa", vacation},
+		{i18n.English, "BBBBBB22", sick},
+		{i18n.English, "bbbbbb22 and more", nil},
+		{i18n.English, "aaa", nil},
+		{i18n.English, "remote", nil},
+		{i18n.German, "urlaub", vacation},
+	}
+
+	for _, c := range cases {
+		got := matchStatus(user, c.words, i18n.Get(c.language))
+		if (nil == got) != (nil == c.want) || (nil != got && got.ID != c.want.ID) {
+			t.Errorf("%q: got %+v, want %+v", c.words, got, c.want)
+		}
+	}
+}
@@ -1,28 +1,31 @@
 package cmd
 
 import (
-	"fmt"
+	"context"
 	"regexp"
 	"strings"
 	"time"
 
 	log "github.com/Sirupsen/logrus"
-	"github.com/italolelis/hanu"
+	"github.com/italolelis/hellowork/i18n"
+	"github.com/italolelis/hellowork/model"
 	"github.com/italolelis/hellowork/repo"
+	"github.com/nlopes/slack"
 )
 
 type UserParam struct {
-	Param string
-	Pattern *regexp.Regexp
+	Param     string
+	Pattern   *regexp.Regexp
+	catalogue *i18n.Catalogue
 }
 
-func NewUserParam(conv hanu.ConversationInterface) (*UserParam, error) {
-	param, err := conv.String("user")
+func NewUserParam(conv *Conversation) (*UserParam, error) {
+	param, err := conv.Param("user")
 	if nil != err || len(param) <= 0 {
 		return nil, err
 	}
 
-	return &UserParam{param, regexp.MustCompile(`<@([a-zA-z0-9]+)>`)}, nil
+	return &UserParam{param, regexp.MustCompile(`<@([a-zA-z0-9]+)>`), conv.Catalogue}, nil
 }
 
 func (u *UserParam) GetUserID() string {
@@ -35,23 +38,20 @@ func (u *UserParam) GetUserID() string {
 }
 
 func (u *UserParam) isEverybody() bool {
-	return strings.ToLower(u.Param) == "everybody"
+	return u.catalogue.IsEverybody(u.Param)
 }
 
 type WhereIs struct {
-	repo repo.Repository
+	client *slack.Client
+	repo   repo.Repository
 }
 
-func NewWhereIs(repo repo.Repository) *WhereIs {
-	return &WhereIs{repo}
+func NewWhereIs(client *slack.Client, repo repo.Repository) *WhereIs {
+	return &WhereIs{client, repo}
 }
 
-func (c *WhereIs) Commands() []string {
-	return []string{
-		"(?i)where is <user>(.*?)",
-		"(?i)is <user> around(.*?)",
-		"(?i)is <user> available(.*?)",
-	}
+func (c *WhereIs) Route() string {
+	return "where_is"
 }
 
 func (c *WhereIs) Name() string {
@@ -62,31 +62,98 @@ func (c *WhereIs) Description() string {
 	return "Finds if an user is available"
 }
 
-func (c *WhereIs) Handler(conv hanu.ConversationInterface) {
+func (c *WhereIs) Handle(conv *Conversation) {
 	userParam, err := NewUserParam(conv)
-	if nil != err {
+	if nil != err || nil == userParam {
 		log.Error(err)
-		conv.Reply("I'm sorry I couldn't understand you")
+		conv.Say("not_understood")
+		return
 	}
 
+	ctx, cancel := newContext()
+	defer cancel()
+
+	asker := c.askerLocation(conv)
 	if userParam.isEverybody() {
-		var msg string
-		users := c.repo.FindAllOut(time.Now())
+		now := time.Now()
+		users, err := c.repo.FindAllOut(ctx, now)
+		if nil != err {
+			conv.SayError(err)
+			return
+		}
+
 		if len(users) > 0 {
-			msg = "This are the people out: \n"
-			for _, user := range users {
-				msg += user.String()
+			lines := make([]string, len(users))
+			for i, user := range users {
+				lines[i] = describeUser(conv.Catalogue, user, user.StatusAt(now), asker)
 			}
-			conv.Reply(msg)
+			conv.Reply(conv.Catalogue.T("people_out") + strings.Join(lines, "\n"))
 		} else {
-			conv.Reply(fmt.Sprintf("As far as I know %s is available", userParam.Param))
+			conv.Say("available", userParam.Param)
 		}
 	} else {
-		user := c.repo.Find(userParam.GetUserID())
-		if nil == user {
-			conv.Reply(fmt.Sprintf("As far as I know %s is available", userParam.Param))
-		} else {
-			conv.Reply(user.String())
+		user, err := c.repo.Find(ctx, userParam.GetUserID())
+		if nil != err && err != repo.ErrNotFound {
+			conv.SayError(err)
+			return
+		}
+
+		var status *model.Status
+		if nil != user {
+			status = user.GetStatus()
+		}
+
+		if nil == status {
+			conv.Say("available", userParam.Param)
+			return
 		}
+
+		conv.Reply(describeUser(conv.Catalogue, user, status, asker))
+		c.announce(ctx, conv, user, status)
+	}
+}
+
+// announce remembers the channel was told about the absence, so it hears
+// about an early return too. Failing to do so isn't worth bothering the
+// asker with
+func (c *WhereIs) announce(ctx context.Context, conv *Conversation, user *model.User, status *model.Status) {
+	channel := conv.Message().Channel
+	if isDirectMessage(channel) {
+		return
 	}
+
+	id := status.ID
+	err := repo.Update(ctx, c.repo, string(user.ID), func(user *model.User) error {
+		return user.AnnounceStatus(id, channel, model.UserID(conv.Message().UserID))
+	})
+	if nil != err {
+		log.WithError(err).Warn("Could not remember the channel was told about the status")
+	}
+}
+
+// askerLocation returns the time zone of the user asking, falling back to
+// the server's one when slack doesn't know it
+func (c *WhereIs) askerLocation(conv *Conversation) *time.Location {
+	slackUser, err := c.client.GetUserInfo(conv.Message().UserID)
+	if nil != err {
+		log.Error(err)
+		return time.Local
+	}
+
+	asker := &model.User{Location: slackUser.TZ}
+	return asker.TimeLocation()
+}
+
+// describeUser renders a status of the user in the catalogue's language and
+// in the user's own time zone, adding when the status ends for the asker if
+// they live in another time zone
+func describeUser(catalogue *i18n.Catalogue, user *model.User, status *model.Status, asker *time.Location) string {
+	loc := user.TimeLocation()
+	from, to := status.From.In(loc), status.To.In(loc)
+	if model.SameZone(to, asker) {
+		return catalogue.T("user_out", user.ID, catalogue.Date(from), catalogue.Weekday(to), catalogue.Date(to))
+	}
+
+	return catalogue.T("user_out_tz", user.ID, catalogue.Date(from), catalogue.Weekday(to), catalogue.Date(to),
+		catalogue.Time(to), catalogue.Date(to.In(asker)), catalogue.Time(to.In(asker)))
 }
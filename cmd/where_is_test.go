@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/italolelis/hellowork/i18n"
+	"github.com/italolelis/hellowork/model"
+)
+
+func TestDescribeUser(t *testing.T) {
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	saoPaulo, _ := time.LoadLocation("America/Sao_Paulo")
+
+	user := model.NewUser("U1")
+	user.Location = "Europe/Berlin"
+	status := model.NewStatus("", time.Date(2018, 2, 19, 0, 0, 0, 0, berlin), time.Date(2018, 2, 23, 23, 59, 59, 0, berlin), model.Vacation)
+
+	cases := []struct {
+		language i18n.Language
+		asker    *time.Location
+		want     string
+	}{
+		{i18n.English, berlin, "<@U1> is out from 19/02/2018 until Friday (23/02/2018)"},
+		{i18n.German, berlin, i18n.Get(i18n.German).T("user_out", "U1", "19.02.2018", "Freitag", "23.02.2018")},
+		{i18n.English, saoPaulo, "<@U1> is out from 19/02/2018 until Friday (23/02/2018) at 23:59 CET their time, which is 23/02/2018 19:59 -03 your time"},
+	}
+
+	for _, c := range cases {
+		if got := describeUser(i18n.Get(c.language), user, status, c.asker); got != c.want {
+			t.Errorf("%s: got %q, want %q", c.language, got, c.want)
+		}
+	}
+}
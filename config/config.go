@@ -1,14 +1,49 @@
 package config
 
-import "github.com/kelseyhightower/envconfig"
+import (
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+)
 
 // Specification for basic configurations
 type Specification struct {
 	LogLevel   string `envconfig:"LOG_LEVEL" default:"info"`
 	SlackToken string `envconfig:"SLACK_TOKEN" required:"true"`
+	// Language is the one the bot speaks when it talks first, such as when a
+	// colleague who is out gets mentioned
+	Language string `envconfig:"LANGUAGE" default:"en"`
+	// StorageDSN is the SQLite database to keep the statuses in, such as
+	// "hellowork.db". They are kept in memory when it's empty
+	StorageDSN string `envconfig:"STORAGE_DSN"`
+	// StoragePath is the embedded bbolt file to keep the statuses in, for
+	// deployments without any database
+	StoragePath string `envconfig:"STORAGE_PATH"`
+	// DatabaseWriteDSN and DatabaseReadDSN are the MongoDB connections to
+	// write and to query the statuses, the read one defaults to the write one
+	DatabaseWriteDSN string `envconfig:"DATABASE_WRITE_DSN"`
+	DatabaseReadDSN  string `envconfig:"DATABASE_READ_DSN"`
+	// Port is where the HTTP server listens, it isn't started when empty
+	Port string `envconfig:"PORT"`
+	// PublicURL is where the HTTP server is reachable from the outside, such
+	// as "https://hellowork.example.com", to hand out links to it
+	PublicURL string `envconfig:"PUBLIC_URL"`
+	// FeedSecret signs the addresses of the calendar feeds, which are off
+	// when it's empty. Changing it invalidates every address given out
+	FeedSecret string `envconfig:"FEED_SECRET"`
+	// APITokens are the tokens the JSON API accepts, which is off when there
+	// is none
+	APITokens []string `envconfig:"API_TOKENS"`
+	// ImportKeywords tell which calendar events are absences, such as
+	// "OOO=out of office,Urlaub=vacation"
+	ImportKeywords string `envconfig:"IMPORT_KEYWORDS" default:"OOO=out of office,Urlaub=vacation,Vacation=vacation"`
+	// CalDAVURLs are the CalDAV collections to import absences from, with
+	// their credentials, polled every ImportInterval
+	CalDAVURLs     []string      `envconfig:"CALDAV_URLS"`
+	ImportInterval time.Duration `envconfig:"IMPORT_INTERVAL" default:"15m"`
 }
 
-//LoadEnv loads environment variables
+// LoadEnv loads environment variables
 func LoadEnv() (*Specification, error) {
 	var config Specification
 	err := envconfig.Process("", &config)
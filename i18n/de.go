@@ -0,0 +1,267 @@
+package i18n
+
+import (
+	"fmt"
+	"strings"
+)
+
+func init() {
+	Register(&Catalogue{
+		Language: German,
+		Routes: map[string][]Route{
+			"status": {
+				{`(?i)Ich bin im (\S+)(.*?)`, []string{"status", "when"}},
+				{`(?i)Ich bin (krank|abwesend|unterwegs)(.*?)`, []string{"status", "when"}},
+				{`(?i)Ich bin (.*?)\s*(?:im|in|auf) (\S+)`, []string{"when", "status"}},
+			},
+			"where_is": {
+				{`(?i)wo ist (\S+?)(\??)`, []string{"user", "rest"}},
+				{`(?i)ist (\S+) (?:da|im Büro|verfügbar|erreichbar)(.*?)`, []string{"user", "rest"}},
+			},
+			"history": {
+				{`(?i)verlauf von (\S+)(.*?)`, []string{"user", "rest"}},
+				{`(?i)zeig (?:mir )?den verlauf von (\S+)(.*?)`, []string{"user", "rest"}},
+			},
+			"rebuild": {
+				{`(?i)stelle die status von (\S+) wieder her(.*?)`, []string{"user", "rest"}},
+			},
+			"cancel": {
+				{`(?i)(?:storniere|lösche|streiche) mein(?:en|e)? (\S+)(.*?)`, []string{"status", "rest"}},
+				{`(?i)(?:storniere|lösche|streiche) status (\S+)(.*?)`, []string{"status", "rest"}},
+			},
+			"back": {
+				{`(?i)ich bin (?:wieder )?zurück(.*?)`, []string{"rest"}},
+				{`(?i)ich bin wieder da(.*?)`, []string{"rest"}},
+			},
+			"move": {
+				{`(?i)verschiebe (?:das )?(ende|anfang|beginn) mein(?:es|er)? (\S+) auf (.*?)`, []string{"edge", "status", "when"}},
+			},
+			"list": {
+				{`(?i)(?:zeig|zeige|liste) (?:mir )?meine (?:abwesenheiten|status)(.*?)`, []string{"rest"}},
+			},
+			"sync_profile": {
+				{`(?i)synchronisiere mein profil mit (\S+)(.*?)`, []string{"token", "rest"}},
+			},
+			"digest": {
+				{`(?i)poste (?:die|eine) übersicht (?:hier )?(?:jeden tag )?um (\S+)(.*?)`, []string{"time", "rest"}},
+			},
+			"calendar": {
+				{`(?i)wer (?:ist|fehlt) (diese woche|nächste woche)(.*?)`, []string{"week", "rest"}},
+				{`(?i)wer (?:ist|fehlt) im (\S+?)(\??)`, []string{"month", "rest"}},
+			},
+			"stop_digest": {
+				{`(?i)(?:stoppe die|keine) übersicht(?: mehr)?(.*?)`, []string{"rest"}},
+			},
+			"import": {
+				{`(?i)importiere meinen kalender (?:von|aus) (\S+)(.*?)`, []string{"url", "rest"}},
+			},
+			"feed": {
+				{`(?i)(?:gib mir )?(?:den |einen )?kalender(?:-feed| feed)? (?:von|für) (\S+)(.*?)`, []string{"user", "rest"}},
+			},
+			"stop_profile_sync": {
+				{`(?i)hör auf,? mein profil zu synchronisieren(.*?)`, []string{"rest"}},
+			},
+		},
+		Messages: map[string]string{
+			"date_too_far":                 "Entschuldigung, dieses Datum liegt zu weit in der Zukunft",
+			"not_understood":               "Entschuldigung, das habe ich nicht verstanden",
+			"not_understood_word":          "Entschuldigung, \"%s\" habe ich nicht verstanden",
+			"ask_until":                    "Ok, und wann bist du wieder da?",
+			"confirm_until":                "Du bist also bis %s (%s) weg, richtig? Sag einfach ja oder nein",
+			"not_understood_until":         "Entschuldigung, ich verstehe nicht, wann du wieder da bist, versuch \"bis Freitag\" oder \"zurück am Montag\"",
+			"followup_cancelled":           "Ok, ich lasse es offen, du kannst das Ende später verschieben",
+			"status_created":               "Ok, du bist vom %s bis %s weg. Viel Spaß!",
+			"status_created_duration":      "Ok, du bist ab %s für %s weg, also bis %s (%s). Viel Spaß!",
+			"available":                    "Soweit ich weiß ist %s da",
+			"people_out":                   "Diese Leute sind nicht da: \n",
+			"user_out":                     "<@%s> ist vom %s bis %s (%s) nicht da",
+			"user_out_tz":                  "<@%s> ist vom %s bis %s (%s) um %s Ortszeit nicht da, bei dir ist das %s %s",
+			"storage_unavailable":          "Entschuldigung, ich komme gerade nicht an meine Notizen. Frag mich bitte gleich nochmal",
+			"storage_error":                "Entschuldigung, bei mir ist etwas schiefgelaufen und ich kann dir das gerade nicht beantworten",
+			"history":                      "Das ist der Verlauf von %s: \n",
+			"history_empty":                "Über %s weiß ich noch nichts",
+			"history_forbidden":            "Entschuldigung, nur Admins können den Verlauf von anderen sehen",
+			"history_status_created":       "%s <@%s> hat %s vom %s bis %s eingetragen",
+			"history_status_edited":        "%s <@%s> hat es auf %s vom %s bis %s geändert",
+			"history_status_cancelled":     "%s <@%s> hat %s vom %s bis %s abgesagt",
+			"history_returned_early":       "%s <@%s> hat %s vom %s vorzeitig beendet, am %s",
+			"history_status_announced":     "%s <@%s> wurde über %s vom %s bis %s informiert",
+			"rebuild_forbidden":            "Entschuldigung, nur Admins können die Status von jemandem wiederherstellen",
+			"rebuilt":                      "Ich habe die Status von %s aus ihrem Verlauf wiederhergestellt",
+			"status_not_found":             "Ich habe bei dir nichts Anstehendes zu \"%s\" gefunden",
+			"status_cancelled":             "Ok, ich habe %s vom %s bis %s gestrichen",
+			"status_moved":                 "Ok, %s geht jetzt vom %s bis %s",
+			"status_invalid_period":        "Entschuldigung, ein Status kann nicht enden, bevor er anfängt",
+			"not_out":                      "Soweit ich weiß warst du gar nicht weg",
+			"welcome_back":                 "Willkommen zurück! Ich habe %s beendet",
+			"mention_out":                  "<@%s> ist bis %s (%s) nicht da (%s)",
+			"user_back":                    "<@%s> ist früher als geplant zurück",
+			"statuses":                     "Das sind deine Abwesenheiten: \n",
+			"statuses_line":                "`%s` %s vom %s bis %s",
+			"statuses_empty":               "Du hast keine anstehenden Abwesenheiten",
+			"profile_status":               "%s bis %s (%s)",
+			"profile_synced":               "Erledigt, dein Profil zeigt deine Status, solange sie gelten",
+			"profile_token_public":         "Bitte schick mir deinen Token nur als Direktnachricht und widerrufe diesen, alle hier können ihn sehen",
+			"profile_token_invalid":        "Entschuldigung, dieser Token scheint nicht deiner zu sein",
+			"profile_not_synced":           "Ich synchronisiere dein Profil nicht",
+			"profile_sync_stopped":         "Ok, ich fasse dein Profil nicht mehr an",
+			"digest":                       "Wer fehlt am %s (%s)",
+			"digest_out":                   "*Heute weg*",
+			"digest_leaving":               "*Ab %s weg*",
+			"digest_returning":             "*Heute zurück*",
+			"digest_line":                  "<@%s> %s bis %s (%s)",
+			"digest_returning_line":        "<@%s> von %s",
+			"digest_nobody":                "Am %s (%s) sind alle da",
+			"digest_scheduled":             "Ok, ich poste hier jeden Werktag um %s, wer fehlt, ab %s (%s)",
+			"digest_invalid_time":          "Entschuldigung, \"%s\" verstehe ich nicht als Uhrzeit, versuch 9:30",
+			"digest_not_found":             "In diesem Channel gibt es keine Übersicht",
+			"digest_stopped":               "Ok, hier keine Übersichten mehr",
+			"calendar_week":                "Woche vom %s",
+			"calendar_nobody":              "Zwischen %s und %s fehlt niemand",
+			"calendar_invalid_period":      "Entschuldigung, ich verstehe nicht wann, versuch \"diese Woche\", \"nächste Woche\" oder \"im März\"",
+			"calendar_legend":              "U Urlaub, K krank, D Dienstreise, H Homeoffice, A abwesend, klein geschrieben für einen halben Tag",
+			"calendar_code_vacation":       "U",
+			"calendar_code_sick":           "K",
+			"calendar_code_work_trip":      "D",
+			"calendar_code_working_remote": "H",
+			"calendar_code_out_of_office":  "A",
+			"import_public":                "Die Adresse deines Kalenders ist geheim, schick sie mir in einer Direktnachricht",
+			"import_failed":                "Entschuldigung, unter dieser Adresse kann ich keinen Kalender lesen",
+			"import_done":                  "Fertig, ich habe %d Status aus deinem Kalender hinzugefügt, %d geändert und %d abgesagt",
+			"feed_private":                 "Jeder mit der Adresse eines Feeds kann ihn lesen, frag mich in einer Direktnachricht",
+			"feed_url":                     "Abonniere diese Adresse in deiner Kalender-App und behalte sie für dich: %s",
+			"feed_name":                    "Wer fehlt",
+			"open_end":                     "offen",
+		},
+		Words: map[string]string{
+			// answers
+			"ja":        "yes",
+			"jep":       "yes",
+			"nein":      "no",
+			"abbrechen": "cancel",
+			"mich":      "me",
+
+			// reasons
+			"urlaub":      "vacation",
+			"urlaubs":     "vacation",
+			"ferien":      "vacation",
+			"krank":       "sick",
+			"dienstreise": "work trip",
+			"homeoffice":  "remote",
+			"abwesend":    "out of office",
+			"unterwegs":   "out of office",
+
+			// range words
+			"ab":       "from",
+			"vom":      "from",
+			"von":      "from",
+			"seit":     "since",
+			"bis":      "until",
+			"zwischen": "between",
+			"und":      "and",
+			"für":      "for",
+			"zurück":   "back",
+			"wieder":   "back",
+			"zum":      "",
+			"zur":      "",
+			"den":      "",
+			"dem":      "",
+
+			// dates
+			"heute":       "today",
+			"morgen":      "tomorrow",
+			"gestern":     "yesterday",
+			"übermorgen":  "in 2 days",
+			"am":          "on",
+			"nächste":     "next",
+			"nächsten":    "next",
+			"nächster":    "next",
+			"nächstes":    "next",
+			"kommende":    "next",
+			"kommenden":   "next",
+			"diese":       "this",
+			"diesen":      "this",
+			"dieser":      "this",
+			"letzte":      "last",
+			"letzten":     "last",
+			"letzter":     "last",
+			"ende":        "end",
+			"anfang":      "start",
+			"beginn":      "start",
+			"vormittag":   "morning",
+			"vormittags":  "morning",
+			"morgens":     "morning",
+			"nachmittag":  "afternoon",
+			"nachmittags": "afternoon",
+			"des":         "of the",
+			"der":         "of the",
+			"ein":         "a",
+			"eine":        "a",
+			"einen":       "a",
+			"einem":       "a",
+			"einer":       "a",
+
+			// units
+			"tag":          "day",
+			"tage":         "days",
+			"tagen":        "days",
+			"woche":        "week",
+			"wochen":       "weeks",
+			"monat":        "month",
+			"monats":       "month",
+			"monate":       "months",
+			"monaten":      "months",
+			"arbeitstag":   "working day",
+			"arbeitstage":  "working days",
+			"arbeitstagen": "working days",
+			"werktag":      "working day",
+			"werktage":     "working days",
+			"werktagen":    "working days",
+
+			// months
+			"januar":   "january",
+			"jänner":   "january",
+			"februar":  "february",
+			"märz":     "march",
+			"mär":      "march",
+			"mai":      "may",
+			"juni":     "june",
+			"juli":     "july",
+			"oktober":  "october",
+			"okt":      "october",
+			"dezember": "december",
+			"dez":      "december",
+
+			// weekdays
+			"montag":     "monday",
+			"dienstag":   "tuesday",
+			"mittwoch":   "wednesday",
+			"donnerstag": "thursday",
+			"freitag":    "friday",
+			"samstag":    "saturday",
+			"sonnabend":  "saturday",
+			"sonntag":    "sunday",
+		},
+		Everybody:  []string{"alle", "jeder"},
+		DateLayout: "02.01.2006",
+		Weekdays:   [7]string{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+		FormatDuration: func(amount int, unit string, businessDays bool) string {
+			names := map[string][2]string{
+				"day":   {"Tag", "Tage"},
+				"week":  {"Woche", "Wochen"},
+				"month": {"Monat", "Monate"},
+			}
+
+			name := names[unit][0]
+			if amount > 1 {
+				name = names[unit][1]
+			}
+
+			if businessDays {
+				name = "Arbeits" + strings.ToLower(name)
+			}
+
+			return fmt.Sprintf("%d %s", amount, name)
+		},
+	})
+}
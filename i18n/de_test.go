@@ -0,0 +1,29 @@
+package i18n
+
+import (
+	"testing"
+	"time"
+
+	"github.com/italolelis/hellowork/model/dateparse"
+)
+
+func TestGermanRanges(t *testing.T) {
+	catalogue := Get(German)
+	for _, input := range []string{
+		"vom 1.3. bis 5.3.",
+		"zwischen 1.3. und 5.3.",
+		"ab 1.3. bis 5.3.",
+	} {
+		r, err := dateparse.Parse(catalogue.Normalize(input))
+		if err != nil {
+			t.Errorf("%q: %v", input, err)
+			continue
+		}
+
+		from, fromOk := r.From.(*dateparse.Calendar)
+		to, toOk := r.To.(*dateparse.Calendar)
+		if !fromOk || !toOk || from.Day != 1 || from.Month != time.March || to.Day != 5 || to.Month != time.March {
+			t.Errorf("%q was parsed as %+v to %+v, want 1 March to 5 March", input, r.From, r.To)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package i18n
+
+import "fmt"
+
+func init() {
+	Register(&Catalogue{
+		Language: English,
+		Routes: map[string][]Route{
+			"status": {
+				{`(?i)I'm on (\S+)(.*?)`, []string{"status", "when"}},
+				{`(?i)I am on (\S+)(.*?)`, []string{"status", "when"}},
+				{`(?i)I'll be on (\S+)(.*?)`, []string{"status", "when"}},
+				{`(?i)I will be on (\S+)(.*?)`, []string{"status", "when"}},
+			},
+			"where_is": {
+				{`(?i)where is (\S+)(.*?)`, []string{"user", "rest"}},
+				{`(?i)is (\S+) around(.*?)`, []string{"user", "rest"}},
+				{`(?i)is (\S+) available(.*?)`, []string{"user", "rest"}},
+			},
+			"history": {
+				{`(?i)history of (\S+)(.*?)`, []string{"user", "rest"}},
+				{`(?i)show (?:me )?the history of (\S+)(.*?)`, []string{"user", "rest"}},
+			},
+			"rebuild": {
+				{`(?i)rebuild the statuses of (\S+)(.*?)`, []string{"user", "rest"}},
+			},
+			"cancel": {
+				{`(?i)cancel my (\S+)(.*?)`, []string{"status", "rest"}},
+				{`(?i)cancel status (\S+)(.*?)`, []string{"status", "rest"}},
+			},
+			"back": {
+				{`(?i)I'm back(.*?)`, []string{"rest"}},
+				{`(?i)I am back(.*?)`, []string{"rest"}},
+			},
+			"move": {
+				{`(?i)move my (\S+) to (start|end) (.*?)`, []string{"status", "edge", "when"}},
+				{`(?i)move status (\S+) to (start|end) (.*?)`, []string{"status", "edge", "when"}},
+			},
+			"list": {
+				{`(?i)(?:list|show) my (?:statuses|absences)(.*?)`, []string{"rest"}},
+			},
+			"sync_profile": {
+				{`(?i)sync my profile with (\S+)(.*?)`, []string{"token", "rest"}},
+			},
+			"stop_profile_sync": {
+				{`(?i)stop syncing my profile(.*?)`, []string{"rest"}},
+			},
+			"digest": {
+				{`(?i)post (?:a|the) digest (?:here )?(?:every day )?at (\S+)(.*?)`, []string{"time", "rest"}},
+				{`(?i)post who is out (?:here )?(?:every day )?at (\S+)(.*?)`, []string{"time", "rest"}},
+			},
+			"calendar": {
+				{`(?i)who(?:'s| is) out (this week|next week)(.*?)`, []string{"week", "rest"}},
+				{`(?i)who(?:'s| is) out in (\S+?)(\??)`, []string{"month", "rest"}},
+			},
+			"stop_digest": {
+				{`(?i)stop (?:posting )?the digest(.*?)`, []string{"rest"}},
+			},
+			"import": {
+				{`(?i)import my (?:calendar|absences) from (\S+)(.*?)`, []string{"url", "rest"}},
+			},
+			"feed": {
+				{`(?i)(?:give me )?(?:the |a )?calendar feed (?:of|for) (\S+)(.*?)`, []string{"user", "rest"}},
+			},
+		},
+		Messages: map[string]string{
+			"date_too_far":                 "I'm sorry, that date is too far ahead",
+			"not_understood":               "I'm sorry I can't understand you",
+			"not_understood_word":          "I'm sorry I can't understand \"%s\"",
+			"ask_until":                    "Ok and when will you be back?",
+			"confirm_until":                "So you are out until %s (%s), right? Just say yes or no",
+			"not_understood_until":         "I'm sorry I can't understand when you will be back, try \"until friday\" or \"back on monday\"",
+			"followup_cancelled":           "Ok, I'll leave it open, you can move it to end later",
+			"status_created":               "Ok you are on vacations from %s until %s. Enjoy!",
+			"status_created_duration":      "Ok you are on vacations from %s for %s, that is until %s (%s). Enjoy!",
+			"available":                    "As far as I know %s is available",
+			"people_out":                   "This are the people out: \n",
+			"user_out":                     "<@%s> is out from %s until %s (%s)",
+			"user_out_tz":                  "<@%s> is out from %s until %s (%s) at %s their time, which is %s %s your time",
+			"storage_unavailable":          "I'm sorry, I can't reach my notes right now. Please ask me again in a moment",
+			"storage_error":                "I'm sorry, something went wrong on my side and I can't answer that right now",
+			"history":                      "This is the history of %s: \n",
+			"history_empty":                "I don't know anything about %s yet",
+			"history_forbidden":            "I'm sorry, only admins can see the history of somebody else",
+			"history_status_created":       "%s <@%s> added %s from %s until %s",
+			"history_status_edited":        "%s <@%s> changed it to %s from %s until %s",
+			"history_status_cancelled":     "%s <@%s> cancelled %s from %s until %s",
+			"history_returned_early":       "%s <@%s> ended %s from %s early, on %s",
+			"history_status_announced":     "%s <@%s> was told about the %s from %s until %s",
+			"rebuild_forbidden":            "I'm sorry, only admins can rebuild the statuses of somebody",
+			"rebuilt":                      "I rebuilt the statuses of %s from their history",
+			"status_not_found":             "I couldn't find any upcoming %s of yours",
+			"status_cancelled":             "Ok, I cancelled your %s from %s until %s",
+			"status_moved":                 "Ok, your %s is now from %s until %s",
+			"status_invalid_period":        "I'm sorry, a status can't end before it starts",
+			"not_out":                      "As far as I know you weren't out",
+			"welcome_back":                 "Welcome back! I ended your %s",
+			"mention_out":                  "<@%s> is out (%s) until %s (%s)",
+			"user_back":                    "<@%s> is back, earlier than planned",
+			"statuses":                     "These are your statuses: \n",
+			"statuses_line":                "`%s` %s from %s until %s",
+			"statuses_empty":               "You have no upcoming statuses",
+			"profile_status":               "%s until %s (%s)",
+			"profile_synced":               "Done, your profile will show your statuses while they last",
+			"profile_token_public":         "Please only send me your token in a direct message, and revoke this one as everybody here can see it",
+			"profile_token_invalid":        "I'm sorry, that token doesn't seem to be yours",
+			"profile_not_synced":           "I'm not syncing your profile",
+			"profile_sync_stopped":         "Ok, I won't touch your profile anymore",
+			"digest":                       "Who is out on %s (%s)",
+			"digest_out":                   "*Out today*",
+			"digest_leaving":               "*Leaving on %s*",
+			"digest_returning":             "*Back today*",
+			"digest_line":                  "<@%s> %s until %s (%s)",
+			"digest_returning_line":        "<@%s> from %s",
+			"digest_nobody":                "Everybody is in on %s (%s)",
+			"digest_scheduled":             "Ok, I'll post who is out here every working day at %s, starting %s (%s)",
+			"digest_invalid_time":          "I'm sorry I can't understand \"%s\" as a time, try 9:30",
+			"digest_not_found":             "There is no digest in this channel",
+			"digest_stopped":               "Ok, no more digests here",
+			"calendar_week":                "Week of %s",
+			"calendar_nobody":              "Nobody is out between %s and %s",
+			"calendar_invalid_period":      "I'm sorry I can't understand when, try \"this week\", \"next week\" or \"in March\"",
+			"calendar_legend":              "V vacation, S sick, T work trip, R remote, O out of office, in lower case for half a day",
+			"calendar_code_vacation":       "V",
+			"calendar_code_sick":           "S",
+			"calendar_code_work_trip":      "T",
+			"calendar_code_working_remote": "R",
+			"calendar_code_out_of_office":  "O",
+			"import_public":                "The address of your calendar is secret, send it to me in a direct message",
+			"import_failed":                "I'm sorry I can't read a calendar at that address",
+			"import_done":                  "Done, I added %d statuses from your calendar, updated %d and cancelled %d",
+			"feed_private":                 "Anybody with the address of a feed can read it, ask me in a direct message",
+			"feed_url":                     "Subscribe to this address in your calendar app, and keep it to yourself: %s",
+			"feed_name":                    "Who is out",
+			"feed_summary":                 "%s: %s",
+			"open_end":                     "you tell me",
+		},
+		Words: map[string]string{
+			"yep":       "yes",
+			"yeah":      "yes",
+			"nope":      "no",
+			"vacations": "vacation",
+			"holiday":   "vacation",
+			"holidays":  "vacation",
+		},
+		Everybody:  []string{"everybody", "everyone"},
+		DateLayout: "02/01/2006",
+		Weekdays:   [7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+		FormatDuration: func(amount int, unit string, businessDays bool) string {
+			if businessDays {
+				unit = "working " + unit
+			}
+
+			if amount > 1 {
+				unit += "s"
+			}
+
+			return fmt.Sprintf("%d %s", amount, unit)
+		},
+	})
+}
@@ -0,0 +1,142 @@
+// Package i18n holds the language catalogues used to understand and answer
+// chat messages. Each language lives in its own file and registers itself
+// on init, so adding a language doesn't require touching any command.
+package i18n
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Language is an ISO 639-1 language code
+type Language string
+
+const (
+	English Language = "en"
+	German  Language = "de"
+)
+
+// Route is a command pattern together with the names of its groups, in the
+// order they appear in the pattern
+type Route struct {
+	Pattern string
+	Params  []string
+}
+
+// Catalogue holds everything the bot needs to talk in one language
+type Catalogue struct {
+	Language Language
+	// Routes are keyed by the command they belong to
+	Routes map[string][]Route
+	// Messages are fmt formats keyed by message id
+	Messages map[string]string
+	// Words maps lower cased words to the english words the date grammar
+	// and model.ParseReason understand. An empty value drops the word
+	Words map[string]string
+	// Everybody are the words used to ask about the whole team
+	Everybody  []string
+	DateLayout string
+	Weekdays   [7]string
+	// FormatDuration renders a duration such as "3 working days"
+	FormatDuration func(amount int, unit string, businessDays bool) string
+}
+
+var (
+	catalogues  = make(map[Language]*Catalogue)
+	wordPattern = regexp.MustCompile(`\pL+`)
+)
+
+// Register adds a catalogue, replacing any previous one for its language
+func Register(c *Catalogue) {
+	catalogues[c.Language] = c
+}
+
+// Get returns the catalogue for a language, falling back to english
+func Get(lang Language) *Catalogue {
+	if c, exists := catalogues[lang]; exists {
+		return c
+	}
+
+	return catalogues[English]
+}
+
+// Catalogues returns every registered catalogue, english first
+func Catalogues() []*Catalogue {
+	var languages []string
+	for lang := range catalogues {
+		if lang != English {
+			languages = append(languages, string(lang))
+		}
+	}
+
+	sort.Strings(languages)
+
+	list := []*Catalogue{catalogues[English]}
+	for _, lang := range languages {
+		list = append(list, catalogues[Language(lang)])
+	}
+
+	return list
+}
+
+// T renders the message with the given id, falling back to the english
+// message and finally to the id itself
+func (c *Catalogue) T(id string, args ...interface{}) string {
+	format, exists := c.Messages[id]
+	if !exists && c.Language != English {
+		return Get(English).T(id, args...)
+	}
+
+	if !exists {
+		format = id
+	}
+
+	return fmt.Sprintf(format, args...)
+}
+
+// Normalize translates every word of the text it knows into english, so the
+// result can be handed to the date grammar and model.ParseReason
+func (c *Catalogue) Normalize(text string) string {
+	if len(c.Words) == 0 {
+		return text
+	}
+
+	text = wordPattern.ReplaceAllStringFunc(text, func(word string) string {
+		if translation, exists := c.Words[strings.ToLower(word)]; exists {
+			return translation
+		}
+
+		return word
+	})
+
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// IsEverybody reports whether the word refers to the whole team
+func (c *Catalogue) IsEverybody(word string) bool {
+	for _, w := range c.Everybody {
+		if strings.EqualFold(w, word) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Date renders a day in the catalogue's layout
+func (c *Catalogue) Date(t time.Time) string {
+	return t.Format(c.DateLayout)
+}
+
+// Time renders the time of the day with its time zone
+func (c *Catalogue) Time(t time.Time) string {
+	return t.Format("15:04 MST")
+}
+
+// Weekday renders the name of the day of the week
+func (c *Catalogue) Weekday(t time.Time) string {
+	return c.Weekdays[t.Weekday()]
+}
@@ -0,0 +1,151 @@
+package importer
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newCalDAVStandIn answers calendar queries of bob like a CalDAV server,
+// with the test events as a single calendar object next to a broken one
+func newCalDAVStandIn(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, _ := r.BasicAuth()
+		if username != "bob" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		body, _ := ioutil.ReadAll(r.Body)
+		if r.Method != "REPORT" || r.Header.Get("Depth") != "1" || !strings.Contains(string(body), "<c:time-range") {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		w.WriteHeader(http.StatusMultiStatus)
+		fmt.Fprintf(w, `<?xml version="1.0"?>
+<d:multistatus xmlns:d="DAV:" xmlns:cal="urn:ietf:params:xml:ns:caldav">
+  <d:response>
+    <d:href>/calendars/bob/absences/a.ics</d:href>
+    <d:propstat>
+      <d:prop><cal:calendar-data>%s</cal:calendar-data></d:prop>
+      <d:status>HTTP/1.1 200 OK</d:status>
+    </d:propstat>
+  </d:response>
+  <d:response>
+    <d:href>/calendars/bob/absences/broken.ics</d:href>
+    <d:propstat>
+      <d:prop><cal:calendar-data>not a calendar</cal:calendar-data></d:prop>
+      <d:status>HTTP/1.1 200 OK</d:status>
+    </d:propstat>
+  </d:response>
+  <d:response>
+    <d:href>/calendars/bob/absences/gone.ics</d:href>
+    <d:propstat>
+      <d:prop><cal:calendar-data/></d:prop>
+      <d:status>HTTP/1.1 404 Not Found</d:status>
+    </d:propstat>
+  </d:response>
+</d:multistatus>`, calendar(testEvents))
+	}))
+}
+
+func TestCalDAVEvents(t *testing.T) {
+	server := newCalDAVStandIn(t)
+	defer server.Close()
+
+	c, err := NewCalDAV(strings.Replace(server.URL, "http://", "http://bob:secret@", 1) + "/calendars/bob/absences/")
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(c.String(), "secret") {
+		t.Errorf("%s shows the password", c)
+	}
+
+	events, err := c.Events(context.Background(), time.Now(), time.Now().AddDate(1, 0, 0))
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	if len(events) != 7 || events[0].UID != "vacation" {
+		t.Errorf("Events returned %d events, want the 7 of the calendar", len(events))
+	}
+
+	wrong, err := NewCalDAV(strings.Replace(server.URL, "http://", "http://bob:wrong@", 1))
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	if _, err := wrong.Events(context.Background(), time.Now(), time.Now()); nil == err {
+		t.Error("Events with the wrong password succeeded")
+	}
+}
+
+func TestFetchICS(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, calendar(testEvents))
+	}))
+	defer server.Close()
+
+	// the stand-in is on the loopback, which FetchICS refuses
+	if _, err := FetchICS(context.Background(), server.URL); err != ErrForbiddenAddress {
+		t.Errorf("FetchICS of the loopback: got %v, want ErrForbiddenAddress", err)
+	}
+
+	defer func(client *http.Client) { icsClient = client }(icsClient)
+	icsClient = server.Client()
+
+	events, err := FetchICS(context.Background(), server.URL)
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	if len(events) != 7 {
+		t.Errorf("FetchICS returned %d events, want 7", len(events))
+	}
+
+	if _, err := FetchICS(context.Background(), "file:///etc/passwd"); nil == err || err == ErrForbiddenAddress {
+		t.Errorf("FetchICS of a file: got %v", err)
+	}
+}
+
+func TestFetchICSRefusesPrivateAddresses(t *testing.T) {
+	for _, address := range []string{"http://localhost/", "http://127.0.0.1:8080/", "webcal://[::1]/", "http://10.0.0.1/", "http://169.254.169.254/latest/meta-data/"} {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		if _, err := FetchICS(ctx, address); err != ErrForbiddenAddress {
+			t.Errorf("FetchICS(%s): got %v, want ErrForbiddenAddress", address, err)
+		}
+		cancel()
+	}
+}
+
+func TestIsPublic(t *testing.T) {
+	for address, want := range map[string]bool{
+		"8.8.8.8":            true,
+		"2001:4860::8888":    true,
+		"172.32.0.1":         true,
+		"127.0.0.1":          false,
+		"::1":                false,
+		"0.0.0.0":            false,
+		"10.1.2.3":           false,
+		"172.16.0.1":         false,
+		"192.168.1.1":        false,
+		"100.64.0.1":         false,
+		"169.254.169.254":    false,
+		"fe80::1":            false,
+		"fd00::1":            false,
+		"224.0.0.1":          false,
+		"::ffff:192.168.0.1": false,
+	} {
+		if got := isPublic(net.ParseIP(address)); got != want {
+			t.Errorf("isPublic(%s) = %v, want %v", address, got, want)
+		}
+	}
+}
@@ -0,0 +1,291 @@
+package importer
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	htime "github.com/italolelis/hellowork/time"
+)
+
+const (
+	icsDateLayout     = "20060102"
+	icsDateTimeLayout = "20060102T150405"
+)
+
+var (
+	ErrInvalidCalendar = errors.New("not an iCalendar file")
+
+	durationPattern = regexp.MustCompile(`^([+-])?P(?:(\d+)W)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)S)?)?$`)
+	icsUnescaper    = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+)
+
+// Event is the part of a VEVENT the importer cares about
+type Event struct {
+	UID         string
+	Summary     string
+	Description string
+	Categories  []string
+	// Organizer is the email of whoever booked the event, if any
+	Organizer string
+	// Start and End are in UTC for dates and floating times, which only get
+	// a time zone once the user is known. End is exclusive, as in the file
+	Start time.Time
+	End   time.Time
+	// AllDay events start and end on dates rather than times
+	AllDay bool
+	// Floating times are wall clock times in the time zone of whoever reads
+	// them
+	Floating  bool
+	Cancelled bool
+	// Recurring events repeat or override an occurrence of one that does
+	Recurring bool
+}
+
+// Period returns when the event happens in the given time zone, the end being
+// the last instant of it as in model.Status
+func (e *Event) Period(loc *time.Location) (time.Time, time.Time) {
+	start, end := e.Start, e.End
+	if e.AllDay || e.Floating {
+		start = inLocation(start, loc)
+		end = inLocation(end, loc)
+	}
+
+	if e.AllDay {
+		return start, htime.EndOfDay(end.AddDate(0, 0, -1))
+	}
+
+	return start.In(loc), end.Add(-time.Nanosecond).In(loc)
+}
+
+// inLocation keeps the wall clock of the time, moving it to the time zone
+func inLocation(t time.Time, loc *time.Location) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+}
+
+// property is a content line, such as DTSTART;TZID=Europe/Berlin:20180101T090000
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// ParseICS reads the events of an iCalendar file. Events it can't make sense
+// of, such as the ones without a start, are left out
+func ParseICS(r io.Reader) ([]*Event, error) {
+	lines, err := unfold(r)
+	if nil != err {
+		return nil, err
+	}
+
+	var (
+		events     []*Event
+		event      *Event
+		duration   time.Duration
+		components []string
+		calendar   bool
+	)
+
+	for _, line := range lines {
+		prop, ok := parseProperty(line)
+		if !ok {
+			continue
+		}
+
+		switch prop.name {
+		case "BEGIN":
+			components = append(components, strings.ToUpper(prop.value))
+			calendar = calendar || components[0] == "VCALENDAR"
+			if len(components) == 2 && components[1] == "VEVENT" {
+				event, duration = &Event{}, 0
+			}
+
+			continue
+		case "END":
+			if len(components) == 2 && nil != event {
+				if event.End.IsZero() {
+					event.End = defaultEnd(event, duration)
+				}
+
+				if len(event.UID) > 0 && !event.Start.IsZero() && event.End.After(event.Start) {
+					events = append(events, event)
+				}
+
+				event = nil
+			}
+
+			if len(components) > 0 {
+				components = components[:len(components)-1]
+			}
+
+			continue
+		}
+
+		// properties of alarms and other nested components don't count
+		if nil == event || len(components) != 2 {
+			continue
+		}
+
+		switch prop.name {
+		case "UID":
+			event.UID = prop.value
+		case "SUMMARY":
+			event.Summary = icsUnescaper.Replace(prop.value)
+		case "DESCRIPTION":
+			event.Description = icsUnescaper.Replace(prop.value)
+		case "CATEGORIES":
+			event.Categories = append(event.Categories, splitText(prop.value)...)
+		case "ORGANIZER":
+			event.Organizer = strings.TrimPrefix(strings.ToLower(prop.value), "mailto:")
+		case "STATUS":
+			event.Cancelled = strings.EqualFold(prop.value, "CANCELLED")
+		case "RRULE", "RDATE", "RECURRENCE-ID":
+			event.Recurring = true
+		case "DTSTART":
+			event.Start, event.AllDay, event.Floating = parseTime(prop)
+		case "DTEND":
+			event.End, _, _ = parseTime(prop)
+		case "DURATION":
+			duration = parseDuration(prop.value)
+		}
+	}
+
+	if !calendar {
+		return nil, ErrInvalidCalendar
+	}
+
+	return events, nil
+}
+
+// unfold joins the lines folded by the writer, which start with a space or a
+// tab
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if len(lines) > 0 && len(line) > 0 && (line[0] == ' ' || line[0] == '\t') {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines, scanner.Err()
+}
+
+// parseProperty splits a content line into its name, parameters and value.
+// Quoted parameter values may contain colons and semicolons
+func parseProperty(line string) (*property, bool) {
+	prop := &property{params: make(map[string]string)}
+	var parts []string
+	start, quoted := 0, false
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == ';' || c == ':':
+			parts = append(parts, line[start:i])
+			start = i + 1
+			if c == ':' {
+				prop.value = line[start:]
+				prop.name = strings.ToUpper(parts[0])
+				for _, param := range parts[1:] {
+					if kv := strings.SplitN(param, "=", 2); len(kv) == 2 {
+						prop.params[strings.ToUpper(kv[0])] = strings.Trim(kv[1], `"`)
+					}
+				}
+
+				return prop, len(prop.name) > 0
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// parseTime reads a date or a date with a time, in UTC, in the zone of its
+// TZID or floating
+func parseTime(prop *property) (t time.Time, allDay bool, floating bool) {
+	value := prop.value
+	if strings.EqualFold(prop.params["VALUE"], "DATE") || len(value) == len(icsDateLayout) {
+		t, err := time.Parse(icsDateLayout, value)
+		return t, nil == err, false
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		t, _ := time.Parse(icsDateTimeLayout, strings.TrimSuffix(value, "Z"))
+		return t, false, false
+	}
+
+	// zones unknown to Go, such as the Windows ones, are taken as floating
+	if loc, err := time.LoadLocation(prop.params["TZID"]); nil == err && len(prop.params["TZID"]) > 0 {
+		t, _ := time.ParseInLocation(icsDateTimeLayout, value, loc)
+		return t.UTC(), false, false
+	}
+
+	t, _ = time.Parse(icsDateTimeLayout, value)
+	return t, false, true
+}
+
+// parseDuration reads a duration such as P1D or PT4H30M, zero when invalid
+func parseDuration(value string) time.Duration {
+	match := durationPattern.FindStringSubmatch(value)
+	if nil == match {
+		return 0
+	}
+
+	var d time.Duration
+	units := []time.Duration{7 * 24 * time.Hour, 24 * time.Hour, time.Hour, time.Minute, time.Second}
+	for i, unit := range units {
+		if n, err := strconv.Atoi(match[i+2]); nil == err {
+			d += time.Duration(n) * unit
+		}
+	}
+
+	if match[1] == "-" {
+		return -d
+	}
+
+	return d
+}
+
+// defaultEnd is the end of an event without DTEND, a day for dates and no
+// time at all for times unless there is a duration
+func defaultEnd(event *Event, duration time.Duration) time.Time {
+	switch {
+	case duration > 0 && event.AllDay:
+		// days of an all-day event are dates, whatever their length in hours
+		return event.Start.AddDate(0, 0, int(duration/(24*time.Hour)))
+	case duration > 0:
+		return event.Start.Add(duration)
+	case event.AllDay:
+		return event.Start.AddDate(0, 0, 1)
+	}
+
+	return event.Start
+}
+
+// splitText splits a list of texts on the commas that aren't escaped
+func splitText(value string) []string {
+	var texts []string
+	start := 0
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '\\':
+			i++
+		case ',':
+			texts = append(texts, icsUnescaper.Replace(value[start:i]))
+			start = i + 1
+		}
+	}
+
+	return append(texts, icsUnescaper.Replace(value[start:]))
+}
@@ -0,0 +1,130 @@
+package importer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/italolelis/hellowork/model"
+)
+
+// feedUIDSuffix ends the UIDs of hellowork's own feeds, which are never
+// imported back
+const feedUIDSuffix = "@hellowork"
+
+// Result counts what an import did to the statuses of a user
+type Result struct {
+	Added     int
+	Updated   int
+	Cancelled int
+}
+
+// Importer turns calendar events into statuses. The calendar is the source of
+// truth for what it imported: importing again updates the statuses whose
+// event changed and cancels the ones whose event was cancelled or deleted
+type Importer struct {
+	keywords Keywords
+}
+
+func NewImporter(keywords Keywords) *Importer {
+	return &Importer{keywords}
+}
+
+// Apply imports the events fetched from the source between now and to into
+// the statuses of the user, leaving out the ones already over at now. The
+// statuses imported from the source before that are in the window but whose
+// event is gone are cancelled. to is zero when the whole calendar was fetched
+func (i *Importer) Apply(user *model.User, source string, events []*Event, now time.Time, to time.Time) Result {
+	var result Result
+	loc := user.TimeLocation()
+	seen := make(map[model.StatusID]bool)
+	for _, event := range events {
+		reason, ok := i.keywords.Reason(event)
+		if !ok || event.Recurring || strings.HasSuffix(event.UID, feedUIDSuffix) {
+			continue
+		}
+
+		id := StatusID(source, event.UID)
+		existing := user.FindStatus(id)
+		if event.Cancelled {
+			if nil != existing && nil == user.CancelStatus(id, user.ID) {
+				result.Cancelled++
+			}
+
+			continue
+		}
+
+		seen[id] = true
+		from, until := event.Period(loc)
+		if until.Before(now) {
+			continue
+		}
+
+		status := model.NewStatus(event.Summary, from, until, reason)
+		status.ID = id
+		switch {
+		case nil != existing:
+			if !sameStatus(existing, status) {
+				status.Channels = existing.Channels
+				if nil == user.EditStatus(status, user.ID) {
+					result.Updated++
+				}
+			}
+		case !alreadyOut(user, status):
+			user.AddStatus(status, user.ID)
+			result.Added++
+		}
+	}
+
+	// looked at on a copy, as cancelling changes the statuses
+	for _, status := range append([]*model.Status(nil), user.Statuses...) {
+		if seen[status.ID] || !IsImportedFrom(status, source) || status.To.Before(now) || (!to.IsZero() && status.From.After(to)) {
+			continue
+		}
+
+		if nil == user.CancelStatus(status.ID, user.ID) {
+			result.Cancelled++
+		}
+	}
+
+	return result
+}
+
+// StatusID is the ID of the status imported from the event of the source,
+// the same on every import so the status can be found again. It ends with
+// the suffix of the source
+func StatusID(source string, uid string) model.StatusID {
+	sum := sha256.Sum256([]byte(uid))
+	return model.StatusID(hex.EncodeToString(sum[:8]) + sourceSuffix(source))
+}
+
+// IsImportedFrom reports whether the status was imported from the source
+func IsImportedFrom(status *model.Status, source string) bool {
+	return strings.HasSuffix(string(status.ID), sourceSuffix(source))
+}
+
+// sourceSuffix tells the statuses of a source apart from the ones of the
+// others and from the ones the user told hellowork about, without giving
+// the source away, since calendar addresses are secret. It comes last so
+// the short IDs the users see stay apart
+func sourceSuffix(source string) string {
+	sum := sha256.Sum256([]byte(source))
+	return "-cal" + hex.EncodeToString(sum[:4])
+}
+
+func sameStatus(a *model.Status, b *model.Status) bool {
+	return a.From.Equal(b.From) && a.To.Equal(b.To) && a.Reason == b.Reason && a.Description == b.Description
+}
+
+// alreadyOut reports whether the user already told hellowork about the
+// absence, with a status of the same reason covering it
+func alreadyOut(user *model.User, status *model.Status) bool {
+	for _, s := range user.Statuses {
+		if s.Reason == status.Reason && !s.From.After(status.From) && (s.To.IsZero() || !s.To.Before(status.To)) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,204 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/italolelis/hellowork/model"
+)
+
+// calendar wraps the events, written with \n, in an iCalendar file
+func calendar(events string) string {
+	return strings.Replace("BEGIN:VCALENDAR\nVERSION:2.0\n"+events+"END:VCALENDAR\n", "\n", "\r\n", -1)
+}
+
+const testEvents = `BEGIN:VEVENT
+UID:vacation
+SUMMARY:Urlaub Mallorca
+DTSTART;VALUE=DATE:20261102
+DTEND;VALUE=DATE:20261107
+ORGANIZER;CN="Wally, W":mailto:Wally@example.com
+BEGIN:VALARM
+SUMMARY:sick reminder
+END:VALARM
+END:VEVENT
+BEGIN:VEVENT
+UID:doctor
+SUMMARY:OOO doctor
+DTSTART;TZID=Europe/Berlin:20261110T130000
+DURATION:PT5H
+END:VEVENT
+BEGIN:VEVENT
+UID:meeting
+SUMMARY:Meeting
+DTSTART:20261110T130000Z
+DTEND:20261110T140000Z
+END:VEVENT
+BEGIN:VEVENT
+UID:category
+SUMMARY:Team sync
+CATEGORIES:Foo,Vacation
+DTSTART;VALUE=DATE:20261201
+END:VEVENT
+BEGIN:VEVENT
+UID:weekly
+SUMMARY:Vacation weekly
+RRULE:FREQ=WEEKLY
+DTSTART;VALUE=DATE:20261201
+END:VEVENT
+BEGIN:VEVENT
+UID:past
+SUMMARY:Vacation
+DTSTART;VALUE=DATE:20200101
+END:VEVENT
+BEGIN:VEVENT
+UID:U1-0001@hellowork
+SUMMARY:wally: vacation
+DTSTART;VALUE=DATE:20261201
+END:VEVENT
+`
+
+// testSource is the address the test events are imported from
+const testSource = "https://calendar.example.com/wally.ics"
+
+func parseTestEvents(t *testing.T, events string) []*Event {
+	parsed, err := ParseICS(strings.NewReader(calendar(events)))
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	return parsed
+}
+
+func newTestImporter(t *testing.T) *Importer {
+	keywords, err := ParseKeywords("OOO=out of office,Urlaub=vacation,Vacation=vacation")
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	return NewImporter(keywords)
+}
+
+func TestParseICS(t *testing.T) {
+	events := parseTestEvents(t, testEvents)
+	if len(events) != 7 {
+		t.Fatalf("ParseICS returned %d events, want 7", len(events))
+	}
+
+	vacation := events[0]
+	if vacation.UID != "vacation" || vacation.Summary != "Urlaub Mallorca" || !vacation.AllDay ||
+		vacation.Organizer != "wally@example.com" {
+		t.Errorf("the vacation is %+v", vacation)
+	}
+
+	from, to := vacation.Period(time.UTC)
+	if !from.Equal(time.Date(2026, 11, 2, 0, 0, 0, 0, time.UTC)) || !to.Equal(time.Date(2026, 11, 7, 0, 0, 0, 0, time.UTC).Add(-time.Nanosecond)) {
+		t.Errorf("the vacation is from %s until %s", from, to)
+	}
+
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	if from, to := events[1].Period(berlin); !from.Equal(time.Date(2026, 11, 10, 13, 0, 0, 0, berlin)) || !to.Equal(time.Date(2026, 11, 10, 18, 0, 0, 0, berlin).Add(-time.Nanosecond)) {
+		t.Errorf("the doctor is from %s until %s", from, to)
+	}
+
+	if !events[4].Recurring {
+		t.Error("the weekly vacation isn't recurring")
+	}
+
+	if _, err := ParseICS(strings.NewReader("hello")); err != ErrInvalidCalendar {
+		t.Errorf("ParseICS of something else: got %v, want ErrInvalidCalendar", err)
+	}
+}
+
+func TestApply(t *testing.T) {
+	now := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+	i := newTestImporter(t)
+	user := model.NewUser("U1")
+	user.Location = "Europe/Berlin"
+
+	// the meeting has no keyword, the weekly vacation repeats, the past one
+	// is over and the last one is a feed of hellowork itself
+	events := parseTestEvents(t, testEvents)
+	if result := i.Apply(user, testSource, events, now, time.Time{}); result != (Result{Added: 3}) {
+		t.Errorf("the first import did %+v, want 3 added", result)
+	}
+
+	if result := i.Apply(user, testSource, events, now, time.Time{}); result != (Result{}) {
+		t.Errorf("importing again did %+v, want nothing", result)
+	}
+
+	changed := strings.Replace(testEvents, "DTEND;VALUE=DATE:20261107", "DTEND;VALUE=DATE:20261108", 1)
+	changed = strings.Replace(changed, "UID:doctor\n", "UID:doctor\nSTATUS:CANCELLED\n", 1)
+	if result := i.Apply(user, testSource, parseTestEvents(t, changed), now, time.Time{}); result != (Result{Updated: 1, Cancelled: 1}) {
+		t.Errorf("importing the changes did %+v, want 1 updated and 1 cancelled", result)
+	}
+
+	if status := user.FindStatus(StatusID(testSource, "vacation")); nil == status || status.Reason != model.Vacation || status.To.Day() != 7 {
+		t.Errorf("the vacation is %+v", status)
+	}
+
+	if nil != user.FindStatus(StatusID(testSource, "doctor")) {
+		t.Error("the cancelled doctor is still there")
+	}
+}
+
+func TestApplyLeavesStatusesAlreadyThere(t *testing.T) {
+	now := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+	user := model.NewUser("U1")
+	user.Location = "Europe/Berlin"
+	user.AddStatus(model.NewStatus("", time.Date(2026, 11, 1, 0, 0, 0, 0, berlin), time.Date(2026, 11, 8, 0, 0, 0, 0, berlin), model.Vacation), "U1")
+
+	result := newTestImporter(t).Apply(user, testSource, parseTestEvents(t, testEvents), now, time.Time{})
+	if nil != user.FindStatus(StatusID(testSource, "vacation")) || result.Added != 2 {
+		t.Errorf("the import did %+v over the vacation the user already had", result)
+	}
+}
+
+func TestApplyCancelsDeletedEvents(t *testing.T) {
+	now := time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)
+	i := newTestImporter(t)
+	user := model.NewUser("U1")
+	user.Location = "Europe/Berlin"
+	told := model.NewStatus("", time.Date(2026, 11, 20, 0, 0, 0, 0, time.UTC), time.Date(2026, 11, 21, 0, 0, 0, 0, time.UTC), model.Sick)
+	user.AddStatus(told, "U1")
+
+	events := parseTestEvents(t, testEvents)
+	i.Apply(user, testSource, events, now, time.Time{})
+	team := parseTestEvents(t, "BEGIN:VEVENT\nUID:offsite\nSUMMARY:OOO offsite\nDTSTART;VALUE=DATE:20261105\nEND:VEVENT\n")
+	i.Apply(user, "https://calendar.example.com/team.ics", team, now, time.Time{})
+
+	// the doctor is deleted, the vacation is renamed to something else and
+	// the category event is after the window, so it's kept whatever it says
+	deleted := strings.Replace(testEvents, "UID:doctor\n", "UID:meeting-2\n", 1)
+	deleted = strings.Replace(deleted, "SUMMARY:Urlaub Mallorca", "SUMMARY:Mallorca", 1)
+	deleted = strings.Replace(deleted, "UID:category\nSUMMARY:Team sync\nCATEGORIES:Foo,Vacation\nDTSTART;VALUE=DATE:20261201\n", "UID:category\nSUMMARY:Team sync\nDTSTART;VALUE=DATE:20261201\n", 1)
+	result := i.Apply(user, testSource, parseTestEvents(t, deleted), now, time.Date(2026, 11, 15, 0, 0, 0, 0, time.UTC))
+	if result != (Result{Cancelled: 2}) {
+		t.Errorf("importing without the events did %+v, want 2 cancelled", result)
+	}
+
+	for _, uid := range []string{"vacation", "doctor"} {
+		if nil != user.FindStatus(StatusID(testSource, uid)) {
+			t.Errorf("the %s is still there", uid)
+		}
+	}
+
+	if nil == user.FindStatus(StatusID(testSource, "category")) {
+		t.Error("the status after the window was cancelled")
+	}
+
+	if nil == user.FindStatus(StatusID("https://calendar.example.com/team.ics", "offsite")) {
+		t.Error("the status of another calendar was cancelled")
+	}
+
+	if nil == user.FindStatus(told.ID) {
+		t.Error("the status the user told about was cancelled")
+	}
+
+	// the past statuses are left as they were
+	if result := i.Apply(user, testSource, nil, time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC), time.Time{}); result != (Result{}) {
+		t.Errorf("importing after the statuses are over did %+v, want nothing", result)
+	}
+}
@@ -0,0 +1,62 @@
+package importer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/italolelis/hellowork/model"
+)
+
+type keyword struct {
+	pattern *regexp.Regexp
+	reason  model.Reason
+}
+
+// Keywords map the words in the summary or the categories of an event to the
+// reason of a status
+type Keywords []keyword
+
+// ParseKeywords reads a list of keywords and their reasons, such as
+// "OOO=out of office,Urlaub=vacation"
+func ParseKeywords(spec string) (Keywords, error) {
+	var keywords Keywords
+	for _, pair := range strings.Split(spec, ",") {
+		if len(strings.TrimSpace(pair)) == 0 {
+			continue
+		}
+
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || len(strings.TrimSpace(kv[0])) == 0 {
+			return nil, fmt.Errorf("invalid keyword %q, it should look like OOO=out of office", pair)
+		}
+
+		// a whole word, but keywords may start or end with punctuation
+		pattern, err := regexp.Compile(`(?i)(?:^|[^\pL\pN])` + regexp.QuoteMeta(strings.TrimSpace(kv[0])) + `(?:$|[^\pL\pN])`)
+		if nil != err {
+			return nil, err
+		}
+
+		keywords = append(keywords, keyword{pattern, model.ParseReason(strings.TrimSpace(kv[1]))})
+	}
+
+	return keywords, nil
+}
+
+// Reason finds the reason of the event. When it has several keywords, the
+// reason that wins over the others is taken, as between statuses
+func (k Keywords) Reason(event *Event) (model.Reason, bool) {
+	texts := append([]string{event.Summary}, event.Categories...)
+
+	var reason model.Reason
+	found := false
+	for _, keyword := range k {
+		for _, text := range texts {
+			if keyword.pattern.MatchString(text) && (!found || keyword.reason.Overrides(reason)) {
+				reason, found = keyword.reason, true
+			}
+		}
+	}
+
+	return reason, found
+}
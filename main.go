@@ -1,13 +1,17 @@
 package main
 
 import (
+	"net/http"
 	"strings"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/italolelis/hanu"
 	"github.com/italolelis/hellowork/cmd"
 	"github.com/italolelis/hellowork/config"
+	"github.com/italolelis/hellowork/i18n"
+	"github.com/italolelis/hellowork/importer"
 	"github.com/italolelis/hellowork/repo"
+	"github.com/italolelis/hellowork/web"
 	"github.com/nlopes/slack"
 )
 
@@ -34,17 +38,103 @@ func init() {
 	log.SetLevel(level)
 }
 
+// newRepository picks the storage backend from the configuration
+func newRepository() (repo.Storage, error) {
+	switch {
+	case len(globalConfig.DatabaseWriteDSN) > 0:
+		log.Debug("Using MongoDB storage")
+		return repo.NewMongo(globalConfig.DatabaseWriteDSN, globalConfig.DatabaseReadDSN)
+	case len(globalConfig.StorageDSN) > 0:
+		log.Debug("Using SQLite storage")
+		return repo.NewSQLite(globalConfig.StorageDSN)
+	case len(globalConfig.StoragePath) > 0:
+		log.Debug("Using bbolt storage")
+		return repo.NewBolt(globalConfig.StoragePath)
+	}
+
+	log.Warn("No DATABASE_WRITE_DSN, STORAGE_DSN or STORAGE_PATH given, statuses will be lost on restart")
+	return repo.NewInMemory(), nil
+}
+
 func main() {
-	inMemoryRepo := repo.NewInMemory()
+	storage, err := newRepository()
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	client := slack.New(globalConfig.SlackToken)
 	bot, err := hanu.NewWithConnection(hanu.NewSlackRTMConnection(client))
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	notifier := cmd.NewSlackNotifier(client)
+	catalogue := i18n.Get(i18n.Language(globalConfig.Language))
+
+	profiles := cmd.NewSlackProfiles()
+	syncer := cmd.NewProfileSyncer(storage, profiles, catalogue)
+	go syncer.Run()
+	// the profiles change as soon as a status is saved, not only when one
+	// starts or ends
+	storage = repo.NewWatched(storage, syncer.Wake)
+
+	mentions := cmd.NewMentions(storage, notifier, catalogue)
+	followups := cmd.NewFollowups(storage, notifier)
+	go cmd.Listen(client, followups, mentions)
+	go cmd.NewDigestScheduler(storage, notifier).Run()
+
+	keywords, err := importer.ParseKeywords(globalConfig.ImportKeywords)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	calendarImporter := importer.NewImporter(keywords)
+	if len(globalConfig.CalDAVURLs) > 0 {
+		var calendars []*importer.CalDAV
+		for _, url := range globalConfig.CalDAVURLs {
+			calendar, err := importer.NewCalDAV(url)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			calendars = append(calendars, calendar)
+		}
+
+		go cmd.NewCalendarPoller(client, storage, calendarImporter, calendars, globalConfig.ImportInterval).Run()
+	}
+
 	cmd.Register(cmd.NewHi())
-	cmd.Register(cmd.NewWhereIs(inMemoryRepo))
-	cmd.Register(cmd.NewStatus(client, inMemoryRepo))
+	cmd.RegisterLocalized(cmd.NewWhereIs(client, storage))
+	cmd.RegisterLocalized(cmd.NewStatus(client, storage, followups))
+	cmd.RegisterLocalized(cmd.NewHistory(client, storage))
+	cmd.RegisterLocalized(cmd.NewRebuild(client, storage))
+	cmd.RegisterLocalized(cmd.NewCancel(storage))
+	cmd.RegisterLocalized(cmd.NewBack(storage, notifier))
+	cmd.RegisterLocalized(cmd.NewMove(storage))
+	cmd.RegisterLocalized(cmd.NewListStatuses(storage))
+	cmd.RegisterLocalized(cmd.NewSyncProfile(client, storage, profiles))
+	cmd.RegisterLocalized(cmd.NewStopProfileSync(storage, profiles))
+	cmd.RegisterLocalized(cmd.NewCalendar(client, storage))
+	cmd.RegisterLocalized(cmd.NewDigest(client, storage))
+	cmd.RegisterLocalized(cmd.NewStopDigest(storage))
+	cmd.RegisterLocalized(cmd.NewImportCalendar(client, storage, calendarImporter))
+
+	mux := http.NewServeMux()
+	if len(globalConfig.FeedSecret) > 0 {
+		feeds := web.NewFeeds(storage, web.NewSlackTeams(client), catalogue, globalConfig.FeedSecret, globalConfig.PublicURL)
+		mux.Handle(web.FeedsPath, feeds)
+		cmd.RegisterLocalized(cmd.NewFeed(feeds))
+	}
+
+	if len(globalConfig.APITokens) > 0 {
+		mux.Handle(web.APIPath, web.NewAPI(storage, globalConfig.APITokens))
+	}
+
+	if len(globalConfig.Port) > 0 {
+		go func() {
+			log.Fatal(http.ListenAndServe(":"+globalConfig.Port, mux))
+		}()
+	}
 
 	cmdList := cmd.List()
 	for _, command := range cmdList {
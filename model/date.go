@@ -0,0 +1,161 @@
+package model
+
+import (
+	"errors"
+	"time"
+
+	"github.com/italolelis/hellowork/model/dateparse"
+	htime "github.com/italolelis/hellowork/time"
+)
+
+var (
+	ErrInvalidDate = errors.New("the date doesn't exist")
+	ErrNoEnd       = errors.New("the message doesn't say when the absence ends")
+	// ErrInvalidPeriod is returned for an absence ending before it starts,
+	// as in "until yesterday"
+	ErrInvalidPeriod = errors.New("the absence ends before it starts")
+	// ErrTooFarAhead is returned for a date after LatestDate, as in
+	// "until 3000"
+	ErrTooFarAhead = errors.New("the date is too far ahead")
+)
+
+// LatestDate is the last date a status can reach. The repositories keep
+// times as unix nanoseconds, which run out in April 2262
+var LatestDate = time.Date(2262, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// ParseTime converts a date expression such as "tomorrow", "20/02/2017",
+// "February 20th", "next friday", "in 3 days" or "end of the month" into a
+// time relative to now in the given IANA time zone
+func ParseTime(date string, location string) (time.Time, error) {
+	now := nowIn(location)
+	d, err := dateparse.ParseDate(date)
+	if err != nil {
+		return now, err
+	}
+
+	t, err := resolveDate(d, now)
+	if nil == err && t.After(LatestDate) {
+		return t, ErrTooFarAhead
+	}
+
+	return t, err
+}
+
+// resolveDate turns a parsed date expression into a time relative to now
+func resolveDate(d dateparse.Date, now time.Time) (time.Time, error) {
+	switch d := d.(type) {
+	case *dateparse.Keyword:
+		switch d.Word {
+		case "tomorrow":
+			return htime.AddDay(now), nil
+		case "yesterday":
+			return htime.SubDay(now), nil
+		}
+
+		return now, nil
+	case *dateparse.Calendar:
+		if d.Year == 0 {
+			return inferYear(d.Day, d.Month, now)
+		}
+
+		return newDate(expandYear(d.Year, now), d.Month, d.Day, now)
+	case *dateparse.Weekday:
+		return resolveWeekday(d.Modifier, d.Day, now), nil
+	case *dateparse.Relative:
+		return addUnits(now, d.Amount, d.Unit), nil
+	case *dateparse.EndOf:
+		return resolveEndOf(d.Next, d.Unit, now), nil
+	}
+
+	return now, ErrInvalidDate
+}
+
+// newDate builds the start of the given day, rejecting dates like 31/02
+// that time.Date would silently normalise
+func newDate(year int, month time.Month, day int, now time.Time) (time.Time, error) {
+	t := time.Date(year, month, day, 0, 0, 0, 0, now.Location())
+	if t.Year() != year || t.Month() != month || t.Day() != day {
+		return now, ErrInvalidDate
+	}
+
+	return t, nil
+}
+
+// inferYear picks the year that puts the day closest to now, so "20/02" said
+// in December means next February while "15/11" said in January means the
+// past November
+func inferYear(day int, month time.Month, now time.Time) (time.Time, error) {
+	t, err := newDate(now.Year(), month, day, now)
+	if err != nil {
+		// 29/02 outside of a leap year is the one of the next leap year,
+		// which is at most eight years away
+		for year := now.Year() + 1; err != nil && year <= now.Year()+8; year++ {
+			t, err = newDate(year, month, day, now)
+		}
+
+		return t, err
+	}
+
+	switch {
+	case t.Before(htime.AddMonths(now, -6)):
+		return newDate(now.Year()+1, month, day, now)
+	case t.After(htime.AddMonths(now, 6)):
+		return newDate(now.Year()-1, month, day, now)
+	}
+
+	return t, nil
+}
+
+// resolveWeekday finds the given weekday relative to now. A bare weekday is
+// its next occurrence (today included), "this" stays in the current week,
+// "next" jumps to the following week and "last" looks back
+func resolveWeekday(modifier string, wd time.Weekday, now time.Time) time.Time {
+	switch modifier {
+	case "this":
+		return htime.Next(htime.SubDay(htime.StartOfWeek(now)), wd)
+	case "next":
+		return htime.Next(htime.SubDay(htime.StartOfWeek(htime.AddWeek(now))), wd)
+	case "last":
+		return htime.Previous(now, wd)
+	}
+
+	if now.Weekday() == wd {
+		return htime.StartOfDay(now)
+	}
+
+	return htime.Next(now, wd)
+}
+
+// addUnits handles "in N days/weeks/months", "next week" and "next month"
+func addUnits(t time.Time, n int, unit dateparse.Unit) time.Time {
+	switch unit {
+	case dateparse.Week:
+		return htime.AddWeeks(t, n)
+	case dateparse.Month:
+		return htime.AddMonthsNoOverflow(t, n)
+	}
+
+	return htime.AddDays(t, n)
+}
+
+// resolveEndOf handles "end of the week/month" and "end of next week/month"
+func resolveEndOf(next bool, unit dateparse.Unit, now time.Time) time.Time {
+	if next {
+		now = addUnits(now, 1, unit)
+	}
+
+	if unit == dateparse.Week {
+		return htime.EndOfWeek(now)
+	}
+
+	return htime.EndOfMonth(now)
+}
+
+// expandYear turns a two digit year into the current century
+func expandYear(year int, now time.Time) int {
+	if year >= 100 {
+		return year
+	}
+
+	return now.Year() - now.Year()%100 + year
+}
@@ -0,0 +1,150 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/italolelis/hellowork/model/dateparse"
+)
+
+func TestResolveDate(t *testing.T) {
+	// a wednesday
+	now := time.Date(2017, 12, 13, 15, 0, 0, 0, time.UTC)
+	cases := []struct {
+		input string
+		want  string
+	}{
+		{"today", "2017-12-13"},
+		{"tomorrow", "2017-12-14"},
+		{"yesterday", "2017-12-12"},
+		{"20/02/2018", "2018-02-20"},
+		{"20/02/18", "2018-02-20"},
+		{"20.02.2018", "2018-02-20"},
+		{"2018-02-20", "2018-02-20"},
+		{"February 20th", "2018-02-20"},
+		{"Feb. 20, 2019", "2019-02-20"},
+		{"20 Feb", "2018-02-20"},
+		{"3rd of march", "2018-03-03"},
+		{"15/11", "2017-11-15"},
+		{"1st of June", "2018-06-01"},
+		{"friday", "2017-12-15"},
+		{"wednesday", "2017-12-13"},
+		{"this friday", "2017-12-15"},
+		{"next friday", "2017-12-22"},
+		{"last monday", "2017-12-11"},
+		{"on tuesday", "2017-12-19"},
+		{"in 3 days", "2017-12-16"},
+		{"in a week", "2017-12-20"},
+		{"next week", "2017-12-20"},
+		{"in 2 months", "2018-02-13"},
+		{"end of the month", "2017-12-31"},
+		{"end of next month", "2018-01-31"},
+	}
+
+	for _, c := range cases {
+		d, err := dateparse.ParseDate(c.input)
+		if err != nil {
+			t.Errorf("ParseDate(%q): %v", c.input, err)
+			continue
+		}
+
+		got, err := resolveDate(d, now)
+		if err != nil {
+			t.Errorf("resolveDate(%q): %v", c.input, err)
+			continue
+		}
+
+		if got.Format("2006-01-02") != c.want {
+			t.Errorf("resolveDate(%q) = %s, want %s", c.input, got.Format("2006-01-02"), c.want)
+		}
+	}
+}
+
+func TestResolveDateRejectsMissingDays(t *testing.T) {
+	now := time.Date(2017, 12, 13, 15, 0, 0, 0, time.UTC)
+	for _, input := range []string{"31/02/2018", "29/02/2018", "31/04", "32/01/2018", "2018-13-01"} {
+		d, err := dateparse.ParseDate(input)
+		if err != nil {
+			continue
+		}
+
+		if got, err := resolveDate(d, now); err != ErrInvalidDate {
+			t.Errorf("resolveDate(%q) = %s, %v, want ErrInvalidDate", input, got, err)
+		}
+	}
+}
+
+func TestInferYear(t *testing.T) {
+	cases := []struct {
+		day   int
+		month time.Month
+		now   string
+		want  string
+	}{
+		{20, time.February, "2017-12-13", "2018-02-20"},
+		{15, time.November, "2018-01-10", "2017-11-15"},
+		{12, time.June, "2017-12-13", "2018-06-12"},
+		{29, time.February, "2027-12-13", "2028-02-29"},
+		{29, time.February, "2026-10-17", "2028-02-29"},
+		{29, time.February, "2028-01-10", "2028-02-29"},
+		{29, time.February, "2029-01-10", "2032-02-29"},
+		// 2100 isn't a leap year
+		{29, time.February, "2097-03-01", "2104-02-29"},
+	}
+
+	for _, c := range cases {
+		now, _ := time.Parse("2006-01-02", c.now)
+		got, err := inferYear(c.day, c.month, now)
+		if err != nil || got.Format("2006-01-02") != c.want {
+			t.Errorf("%d/%d said on %s = %s, %v, want %s", c.day, c.month, c.now, got.Format("2006-01-02"), err, c.want)
+		}
+	}
+
+	if _, err := inferYear(31, time.April, time.Date(2017, 12, 13, 0, 0, 0, 0, time.UTC)); err != ErrInvalidDate {
+		t.Errorf("31/04 = %v, want ErrInvalidDate", err)
+	}
+}
+
+func TestTimableMention(t *testing.T) {
+	now := time.Date(2017, 12, 13, 15, 0, 0, 0, time.UTC)
+	layout := "2006-01-02 15:04"
+	cases := []struct {
+		input string
+		from  string
+		to    string
+	}{
+		{"from tomorrow until friday", "2017-12-14 00:00", "2017-12-15 23:59"},
+		{"until friday", "2017-12-13 00:00", "2017-12-15 23:59"},
+		{"20-24 feb", "2018-02-20 00:00", "2018-02-24 23:59"},
+		{"from 28/12 until 2/1", "2017-12-28 00:00", "2018-01-02 23:59"},
+		{"back on monday", "2017-12-13 00:00", "2017-12-17 23:59"},
+		{"back on monday afternoon", "2017-12-13 00:00", "2017-12-18 11:59"},
+		{"tomorrow afternoon", "2017-12-14 12:00", "2017-12-14 23:59"},
+		{"for 3 working days", "2017-12-13 00:00", "2017-12-15 23:59"},
+		{"from friday for 2 working days", "2017-12-15 00:00", "2017-12-18 23:59"},
+		{"tomorrow", "2017-12-14 00:00", ""},
+	}
+
+	for _, c := range cases {
+		r, err := dateparse.Parse(c.input)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", c.input, err)
+			continue
+		}
+
+		m, err := newTimableMention(r, now)
+		if err != nil {
+			t.Errorf("newTimableMention(%q): %v", c.input, err)
+			continue
+		}
+
+		to := ""
+		if m.HasTo {
+			to = m.To.Format(layout)
+		}
+
+		if m.From.Format(layout) != c.from || to != c.to {
+			t.Errorf("%q = %s until %q, want %s until %q", c.input, m.From.Format(layout), to, c.from, c.to)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package dateparse
+
+import "time"
+
+// Unit is the granularity of relative dates and durations
+type Unit int
+
+const (
+	Day Unit = iota
+	Week
+	Month
+)
+
+func (u Unit) String() string {
+	switch u {
+	case Week:
+		return "week"
+	case Month:
+		return "month"
+	}
+
+	return "day"
+}
+
+// DayPart narrows a day down to its morning or its afternoon
+type DayPart int
+
+const (
+	WholeDay DayPart = iota
+	Morning
+	Afternoon
+)
+
+// Date is a single date expression. It's implemented by Keyword, Calendar,
+// Weekday, Relative and EndOf
+type Date interface {
+	Pos() int
+	date()
+}
+
+// Keyword is one of today, tomorrow or yesterday
+type Keyword struct {
+	Word string
+	At   int
+}
+
+// Calendar is an absolute date such as 20/02/2017 or "February 20th".
+// Year is zero when it has to be inferred and Month is zero for the bare day
+// on the left side of "20-24 Feb" until the range fills it in
+type Calendar struct {
+	Year  int
+	Month time.Month
+	Day   int
+	At    int
+}
+
+// Weekday is a day of the week with an optional this, next, last or on
+// modifier
+type Weekday struct {
+	Modifier string
+	Day      time.Weekday
+	At       int
+}
+
+// Relative is an offset from now, as in "in 3 days" or "next week"
+type Relative struct {
+	Amount int
+	Unit   Unit
+	At     int
+}
+
+// EndOf is the end of the current or the next week or month
+type EndOf struct {
+	Next bool
+	Unit Unit
+	At   int
+}
+
+func (d *Keyword) Pos() int  { return d.At }
+func (d *Calendar) Pos() int { return d.At }
+func (d *Weekday) Pos() int  { return d.At }
+func (d *Relative) Pos() int { return d.At }
+func (d *EndOf) Pos() int    { return d.At }
+
+func (*Keyword) date()  {}
+func (*Calendar) date() {}
+func (*Weekday) date()  {}
+func (*Relative) date() {}
+func (*EndOf) date()    {}
+
+// Duration is the length of an absence, as in "2 weeks" or "3 working days"
+type Duration struct {
+	Amount       int
+	Unit         Unit
+	BusinessDays bool
+	At           int
+}
+
+// Range is the parsed form of a whole absence mention. Any of its fields may
+// be missing: "until friday" has no From and "from monday" has neither To
+// nor Duration. When Back is set To is the day the user returns rather than
+// the last day away
+type Range struct {
+	From     Date
+	FromPart DayPart
+	To       Date
+	ToPart   DayPart
+	Duration *Duration
+	Back     bool
+}
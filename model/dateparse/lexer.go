@@ -0,0 +1,82 @@
+package dateparse
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// TokenKind represents the kind of a lexical token
+type TokenKind int
+
+const (
+	EOF TokenKind = iota
+	Word
+	Number
+	Punct
+)
+
+func (k TokenKind) String() string {
+	switch k {
+	case Word:
+		return "word"
+	case Number:
+		return "number"
+	case Punct:
+		return "punctuation"
+	}
+
+	return "end of message"
+}
+
+// Token is a single lexical unit of a message. Words are lower cased and Pos
+// is the byte offset of the token in the original message
+type Token struct {
+	Kind TokenKind
+	Text string
+	Pos  int
+}
+
+// Tokenize splits a message into words, numbers and punctuation, dropping
+// white space. The returned slice always ends with an EOF token
+func Tokenize(input string) []Token {
+	var tokens []Token
+
+	for i := 0; i < len(input); {
+		r, size := utf8.DecodeRuneInString(input[i:])
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+		case unicode.IsLetter(r):
+			end := scan(input, i, unicode.IsLetter)
+			tokens = append(tokens, Token{Word, strings.ToLower(input[i:end]), i})
+			i = end
+		case isDigit(r):
+			end := scan(input, i, isDigit)
+			tokens = append(tokens, Token{Number, input[i:end], i})
+			i = end
+		default:
+			tokens = append(tokens, Token{Punct, input[i : i+size], i})
+			i += size
+		}
+	}
+
+	return append(tokens, Token{EOF, "", len(input)})
+}
+
+// scan returns the offset of the first rune after start that doesn't satisfy f
+func scan(input string, start int, f func(rune) bool) int {
+	for i, r := range input[start:] {
+		if !f(r) {
+			return start + i
+		}
+	}
+
+	return len(input)
+}
+
+// isDigit only accepts ASCII digits so every Number token can be converted
+// with strconv
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
@@ -0,0 +1,718 @@
+// Package dateparse turns the free text of a chat message into a typed syntax
+// tree of the absence it describes, e.g. "from monday until 20 Feb" or
+// "for 3 working days". Resolving the tree into actual times is left to the
+// caller, who knows the current time and the user's location.
+package dateparse
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const (
+	// maxDigits keeps numbers small enough that no date arithmetic can
+	// overflow or loop for long
+	maxDigits = 4
+	// maxTokens bounds the work done on a single message, the dates people
+	// write come way before that many words
+	maxTokens = 100
+)
+
+var (
+	keywords = map[string]bool{"today": true, "tomorrow": true, "yesterday": true}
+
+	months = map[string]time.Month{
+		"january": time.January, "jan": time.January,
+		"february": time.February, "feb": time.February,
+		"march": time.March, "mar": time.March,
+		"april": time.April, "apr": time.April,
+		"may":  time.May,
+		"june": time.June, "jun": time.June,
+		"july": time.July, "jul": time.July,
+		"august": time.August, "aug": time.August,
+		"september": time.September, "sept": time.September, "sep": time.September,
+		"october": time.October, "oct": time.October,
+		"november": time.November, "nov": time.November,
+		"december": time.December, "dec": time.December,
+	}
+
+	weekdays = map[string]time.Weekday{
+		"monday": time.Monday, "mon": time.Monday,
+		"tuesday": time.Tuesday, "tues": time.Tuesday, "tue": time.Tuesday,
+		"wednesday": time.Wednesday, "wed": time.Wednesday,
+		"thursday": time.Thursday, "thurs": time.Thursday, "thur": time.Thursday, "thu": time.Thursday,
+		"friday": time.Friday, "fri": time.Friday,
+		"saturday": time.Saturday, "sat": time.Saturday,
+		"sunday": time.Sunday, "sun": time.Sunday,
+	}
+
+	units = map[string]Unit{
+		"day": Day, "days": Day,
+		"week": Week, "weeks": Week,
+		"month": Month, "months": Month,
+	}
+
+	ordinals = map[string]bool{"st": true, "nd": true, "rd": true, "th": true}
+
+	dayParts = map[string]DayPart{"morning": Morning, "afternoon": Afternoon, "am": Morning, "pm": Afternoon}
+
+	// rangeStarts are the words that commit the parser to a range, so an
+	// error after them is reported instead of being skipped as noise
+	rangeStarts = map[string]bool{
+		"from": true, "since": true, "until": true, "till": true, "to": true,
+		"between": true, "back": true, "for": true,
+	}
+	rangeEnds = map[string]bool{"until": true, "till": true, "to": true, "through": true}
+)
+
+// ParseError describes the token the parser couldn't make sense of
+type ParseError struct {
+	Pos   int
+	Token string
+	Msg   string
+}
+
+func (e *ParseError) Error() string {
+	if e.Token == "" {
+		return fmt.Sprintf("%s at the end of the message", e.Msg)
+	}
+
+	return fmt.Sprintf("%s at position %d near %q", e.Msg, e.Pos, e.Token)
+}
+
+type parser struct {
+	tokens []Token
+	pos    int
+	// dated is set once the current attempt parsed a whole date
+	dated bool
+}
+
+// Parse finds the first absence range in the message, skipping any words
+// that come before it. When nothing can be parsed the error points at the
+// furthest token the parser got to. Only the first maxTokens tokens are
+// looked at
+func Parse(input string) (*Range, error) {
+	tokens := Tokenize(input)
+	if len(tokens) > maxTokens+1 {
+		tokens = append(tokens[:maxTokens:maxTokens], Token{EOF, "", tokens[maxTokens].Pos})
+	}
+
+	p := &parser{tokens: tokens}
+
+	// a failed attempt starts over after the tokens it consumed, which keeps
+	// the work linear and stops attempts from inside the tokens of a failed
+	// one from repeating its error less accurately
+	var furthest *ParseError
+	for start := 0; p.tokens[start].Kind != EOF; {
+		p.pos, p.dated = start, false
+		committed := p.commits()
+
+		r, err := p.parseRange()
+		if err == nil {
+			return r, nil
+		}
+
+		// a range that got past its first date is what the message meant,
+		// trying further would only find a part of it
+		if committed && p.dated {
+			return nil, err
+		}
+
+		if committed {
+			if perr, ok := err.(*ParseError); ok && (furthest == nil || perr.Pos > furthest.Pos) {
+				furthest = perr
+			}
+		}
+
+		if p.pos > start {
+			start = p.pos
+		} else {
+			start++
+		}
+	}
+
+	if furthest != nil {
+		return nil, furthest
+	}
+
+	return nil, p.errorf(p.tokens[len(p.tokens)-1], "couldn't find a date")
+}
+
+// ParseDate parses a message that consists of a single date expression
+func ParseDate(input string) (Date, error) {
+	p := &parser{tokens: Tokenize(input)}
+	d, err := p.parseDate()
+	if err != nil {
+		return nil, err
+	}
+
+	if c, ok := d.(*Calendar); ok && c.Month == 0 {
+		return nil, p.errorf(p.peek(), "expected a month")
+	}
+
+	if err := p.expectEOF(); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// ParseRange parses a message that consists of a single range, without any
+// words around it
+func ParseRange(input string) (*Range, error) {
+	p := &parser{tokens: Tokenize(input)}
+	r, err := p.parseRange()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.expectEOF(); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// ParseDuration parses a message that consists of a single duration
+func ParseDuration(input string) (*Duration, error) {
+	p := &parser{tokens: Tokenize(input)}
+	d, err := p.parseDuration()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.expectEOF(); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// commits reports whether the current token can only be the start of a date
+// expression, as opposed to words like "on" or "in" that are often just noise
+func (p *parser) commits() bool {
+	t := p.peek()
+	if t.Kind == Number {
+		return true
+	}
+
+	_, isMonth := months[t.Text]
+	_, isWeekday := weekdays[t.Text]
+
+	return t.Kind == Word && (rangeStarts[t.Text] || keywords[t.Text] || isMonth || isWeekday)
+}
+
+// parseRange parses:
+//
+//	range := "between" datepart "and" datepart
+//	       | ("from" | "since") datepart [tail]
+//	       | ("until" | "till" | "to" | "through") datepart
+//	       | "back" ["on"] datepart
+//	       | "for" duration
+//	       | datepart [tail]
+func (p *parser) parseRange() (*Range, error) {
+	r := &Range{}
+
+	var err error
+	t := p.peek()
+	if t.Kind == Word {
+		switch t.Text {
+		case "between":
+			p.next()
+			if r.From, r.FromPart, err = p.parseDatePart(); err != nil {
+				return nil, err
+			}
+
+			if err := p.expect("and"); err != nil {
+				return nil, err
+			}
+
+			if r.To, r.ToPart, err = p.parseDatePart(); err != nil {
+				return nil, err
+			}
+
+			return p.complete(r)
+		case "from", "since":
+			p.next()
+			if r.From, r.FromPart, err = p.parseDatePart(); err != nil {
+				return nil, err
+			}
+
+			return p.parseTail(r)
+		case "until", "till", "to", "through":
+			p.next()
+			if r.To, r.ToPart, err = p.parseDatePart(); err != nil {
+				return nil, err
+			}
+
+			return p.complete(r)
+		case "back":
+			p.next()
+			p.accept("on")
+			if r.To, r.ToPart, err = p.parseDatePart(); err != nil {
+				return nil, err
+			}
+
+			r.Back = true
+
+			return p.complete(r)
+		case "for":
+			p.next()
+			if r.Duration, err = p.parseDuration(); err != nil {
+				return nil, err
+			}
+
+			return r, nil
+		}
+	}
+
+	if r.From, r.FromPart, err = p.parseDatePart(); err != nil {
+		return nil, err
+	}
+
+	return p.parseTail(r)
+}
+
+// parseTail parses the end of a range:
+//
+//	tail := ("until" | "till" | "to" | "through" | "-") datepart
+//	      | "for" duration
+func (p *parser) parseTail(r *Range) (*Range, error) {
+	var err error
+	t := p.peek()
+	switch {
+	case t.Kind == Word && rangeEnds[t.Text], t.Kind == Punct && t.Text == "-":
+		p.next()
+		if r.To, r.ToPart, err = p.parseDatePart(); err != nil {
+			return nil, err
+		}
+	case t.Kind == Word && t.Text == "for":
+		p.next()
+		if r.Duration, err = p.parseDuration(); err != nil {
+			return nil, err
+		}
+	}
+
+	return p.complete(r)
+}
+
+// parseDatePart parses:
+//
+//	datepart := date [["in" "the"] ("morning" | "afternoon" | "am" | "pm")]
+func (p *parser) parseDatePart() (Date, DayPart, error) {
+	d, err := p.parseDate()
+	if err != nil {
+		return nil, WholeDay, err
+	}
+
+	offset := 0
+	if p.peek().Text == "in" && p.peekAt(1).Text == "the" {
+		offset = 2
+	}
+
+	p.dated = true
+	if part, ok := dayParts[p.peekAt(offset).Text]; ok {
+		p.pos += offset + 1
+		return d, part, nil
+	}
+
+	return d, WholeDay, nil
+}
+
+// complete lets the bare day of "20-24 Feb" borrow the month and year of the
+// other end of the range, and rejects bare days that are left over
+func (p *parser) complete(r *Range) (*Range, error) {
+	from, fromOk := r.From.(*Calendar)
+	to, toOk := r.To.(*Calendar)
+	if fromOk && toOk && from.Month == 0 && to.Month != 0 {
+		from.Month, from.Year = to.Month, to.Year
+	}
+
+	for _, d := range []Date{r.From, r.To} {
+		if c, ok := d.(*Calendar); ok && c.Month == 0 {
+			return nil, &ParseError{Pos: c.At, Token: strconv.Itoa(c.Day), Msg: "expected a month"}
+		}
+	}
+
+	return r, nil
+}
+
+// parseDate parses:
+//
+//	date := "today" | "tomorrow" | "yesterday"
+//	      | ("this" | "next" | "last" | "on") weekday
+//	      | "this" ("morning" | "afternoon")
+//	      | "next" ("week" | "month")
+//	      | "on" date   (but not "on" again)
+//	      | "in" amount unit
+//	      | "end" ["of"] ["the"] ["this" | "next"] ("week" | "month")
+//	      | weekday
+//	      | number ...
+//	      | month ...
+func (p *parser) parseDate() (Date, error) {
+	t := p.peek()
+	switch t.Kind {
+	case Number:
+		return p.parseNumericDate()
+	case Word:
+	default:
+		return nil, p.errorf(t, "expected a date")
+	}
+
+	if keywords[t.Text] {
+		p.next()
+		return &Keyword{Word: t.Text, At: t.Pos}, nil
+	}
+
+	if wd, ok := weekdays[t.Text]; ok {
+		p.next()
+		return &Weekday{Day: wd, At: t.Pos}, nil
+	}
+
+	if _, ok := months[t.Text]; ok {
+		return p.parseMonthFirstDate()
+	}
+
+	switch t.Text {
+	case "on":
+		p.next()
+		if wd, ok := weekdays[p.peek().Text]; ok {
+			p.next()
+			return &Weekday{Modifier: "on", Day: wd, At: t.Pos}, nil
+		}
+
+		if n := p.peek(); n.Text == "on" {
+			return nil, p.errorf(n, "expected a date")
+		}
+
+		return p.parseDate()
+	case "this", "next", "last":
+		p.next()
+		n := p.peek()
+		if _, ok := dayParts[n.Text]; ok && t.Text == "this" {
+			// leave the part to parseDatePart, as in "this afternoon"
+			return &Keyword{Word: "today", At: t.Pos}, nil
+		}
+
+		if wd, ok := weekdays[n.Text]; ok {
+			p.next()
+			return &Weekday{Modifier: t.Text, Day: wd, At: t.Pos}, nil
+		}
+
+		if u, ok := units[n.Text]; ok && t.Text == "next" && u != Day {
+			p.next()
+			return &Relative{Amount: 1, Unit: u, At: t.Pos}, nil
+		}
+
+		return nil, p.errorf(n, "expected a weekday")
+	case "in":
+		p.next()
+		amount, err := p.parseAmount()
+		if err != nil {
+			return nil, err
+		}
+
+		u, err := p.parseUnit()
+		if err != nil {
+			return nil, err
+		}
+
+		return &Relative{Amount: amount, Unit: u, At: t.Pos}, nil
+	case "end":
+		p.next()
+		p.accept("of")
+		p.accept("the")
+		next := p.accept("next")
+		if !next {
+			p.accept("this")
+		}
+
+		n := p.peek()
+		if u, ok := units[n.Text]; ok && u != Day {
+			p.next()
+			return &EndOf{Next: next, Unit: u, At: t.Pos}, nil
+		}
+
+		return nil, p.errorf(n, "expected week or month")
+	}
+
+	return nil, p.errorf(t, "expected a date")
+}
+
+// parseNumericDate parses:
+//
+//	number := yyyy "-" mm "-" dd
+//	        | dd ("/" | ".") mm [("/" | ".") yy[yy]] ["."]
+//	        | dd ["." | "st" | "nd" | "rd" | "th"] ["of"] month [","] [yyyy]
+//	        | dd ["."]   (only before the end of a range, as in "20-24 Feb")
+func (p *parser) parseNumericDate() (Date, error) {
+	first := p.next()
+	if err := p.checkNumber(first); err != nil {
+		return nil, err
+	}
+
+	sep := p.peek()
+	switch {
+	case len(first.Text) == 4 && sep.Text == "-":
+		p.next()
+		month, err := p.parseNumber()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := p.expect("-"); err != nil {
+			return nil, err
+		}
+
+		day, err := p.parseNumber()
+		if err != nil {
+			return nil, err
+		}
+
+		return &Calendar{Year: atoi(first.Text), Month: time.Month(month), Day: day, At: first.Pos}, nil
+	case (sep.Text == "/" || sep.Text == ".") && p.peekAt(1).Kind == Number:
+		p.next()
+		month, err := p.parseNumber()
+		if err != nil {
+			return nil, err
+		}
+
+		c := &Calendar{Month: time.Month(month), Day: atoi(first.Text), At: first.Pos}
+		if s := p.peek(); (s.Text == "/" || s.Text == ".") && p.peekAt(1).Kind == Number {
+			p.next()
+			year := p.next()
+			if err := p.checkNumber(year); err != nil {
+				return nil, err
+			}
+
+			if len(year.Text) != 2 && len(year.Text) != 4 {
+				return nil, p.errorf(year, "expected a year")
+			}
+
+			c.Year = atoi(year.Text)
+		}
+
+		// the german "1.3." ends with a dot too
+		if s := p.peek(); s.Text == "." {
+			p.next()
+		}
+
+		return c, nil
+	case sep.Text == "-" && p.peekAt(1).Kind == Number:
+		return &Calendar{Day: atoi(first.Text), At: first.Pos}, nil
+	}
+
+	if !p.acceptPunct(".") && ordinals[p.peek().Text] {
+		p.next()
+	}
+
+	if n := p.peek(); rangeEnds[n.Text] && n.Kind == Word && p.peekAt(1).Kind == Number {
+		return &Calendar{Day: atoi(first.Text), At: first.Pos}, nil
+	}
+
+	p.accept("of")
+	m, ok := months[p.peek().Text]
+	if !ok {
+		return nil, p.errorf(p.peek(), "expected a month")
+	}
+
+	p.next()
+	p.acceptPunct(".")
+	c := &Calendar{Month: m, Day: atoi(first.Text), At: first.Pos}
+	c.Year = p.parseOptionalYear()
+
+	return c, nil
+}
+
+// parseMonthFirstDate parses:
+//
+//	month := month ["."] dd ["st" | "nd" | "rd" | "th"] [","] [yyyy]
+func (p *parser) parseMonthFirstDate() (Date, error) {
+	t := p.next()
+	p.acceptPunct(".")
+
+	day, err := p.parseNumber()
+	if err != nil {
+		return nil, err
+	}
+
+	if ordinals[p.peek().Text] {
+		p.next()
+	}
+
+	c := &Calendar{Month: months[t.Text], Day: day, At: t.Pos}
+	c.Year = p.parseOptionalYear()
+
+	return c, nil
+}
+
+// parseOptionalYear consumes a trailing four digit year, optionally after a
+// comma, and returns zero when there is none
+func (p *parser) parseOptionalYear() int {
+	offset := 0
+	if p.peek().Text == "," {
+		offset = 1
+	}
+
+	year := p.peekAt(offset)
+	if year.Kind != Number || len(year.Text) != 4 {
+		return 0
+	}
+
+	p.pos += offset + 1
+
+	return atoi(year.Text)
+}
+
+// parseDuration parses:
+//
+//	duration := amount ["working" | "business" | "work"] unit
+func (p *parser) parseDuration() (*Duration, error) {
+	at := p.peek().Pos
+	amount, err := p.parseAmount()
+	if err != nil {
+		return nil, err
+	}
+
+	business := p.accept("working", "business", "work")
+	u, err := p.parseUnit()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Duration{Amount: amount, Unit: u, BusinessDays: business, At: at}, nil
+}
+
+// parseAmount parses:
+//
+//	amount := number | "a" | "an" | "one"
+func (p *parser) parseAmount() (int, error) {
+	t := p.peek()
+	if t.Kind == Word && (t.Text == "a" || t.Text == "an" || t.Text == "one") {
+		p.next()
+		return 1, nil
+	}
+
+	n, err := p.parseNumber()
+	if err != nil {
+		return 0, err
+	}
+
+	if n < 1 {
+		return 0, p.errorf(p.tokens[p.pos-1], "expected a positive amount")
+	}
+
+	return n, nil
+}
+
+func (p *parser) parseUnit() (Unit, error) {
+	t := p.peek()
+	u, ok := units[t.Text]
+	if !ok || t.Kind != Word {
+		return Day, p.errorf(t, "expected days, weeks or months")
+	}
+
+	p.next()
+
+	return u, nil
+}
+
+func (p *parser) parseNumber() (int, error) {
+	t := p.peek()
+	if err := p.checkNumber(t); err != nil {
+		return 0, err
+	}
+
+	p.next()
+
+	return atoi(t.Text), nil
+}
+
+func (p *parser) checkNumber(t Token) error {
+	if t.Kind != Number {
+		return p.errorf(t, "expected a number")
+	}
+
+	if len(t.Text) > maxDigits {
+		return p.errorf(t, "number is too large")
+	}
+
+	return nil
+}
+
+func (p *parser) peek() Token {
+	return p.peekAt(0)
+}
+
+// peekAt looks ahead without consuming, returning EOF past the end
+func (p *parser) peekAt(n int) Token {
+	if p.pos+n >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+
+	return p.tokens[p.pos+n]
+}
+
+func (p *parser) next() Token {
+	t := p.peek()
+	if t.Kind != EOF {
+		p.pos++
+	}
+
+	return t
+}
+
+// accept consumes the current token if it's one of the given words
+func (p *parser) accept(words ...string) bool {
+	t := p.peek()
+	if t.Kind != Word {
+		return false
+	}
+
+	for _, w := range words {
+		if t.Text == w {
+			p.next()
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *parser) acceptPunct(s string) bool {
+	if t := p.peek(); t.Kind == Punct && t.Text == s {
+		p.next()
+		return true
+	}
+
+	return false
+}
+
+func (p *parser) expect(text string) error {
+	t := p.peek()
+	if t.Text != text {
+		return p.errorf(t, "expected %q", text)
+	}
+
+	p.next()
+
+	return nil
+}
+
+func (p *parser) expectEOF() error {
+	if t := p.peek(); t.Kind != EOF {
+		return p.errorf(t, "unexpected %s", t.Kind)
+	}
+
+	return nil
+}
+
+func (p *parser) errorf(t Token, format string, args ...interface{}) *ParseError {
+	return &ParseError{Pos: t.Pos, Token: t.Text, Msg: fmt.Sprintf(format, args...)}
+}
+
+func atoi(s string) int {
+	i, _ := strconv.Atoi(s)
+	return i
+}
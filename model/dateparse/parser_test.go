@@ -0,0 +1,221 @@
+package dateparse
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// describe renders a range without the positions, to compare it in tables
+func describe(r *Range) string {
+	var parts []string
+	if r.From != nil {
+		parts = append(parts, "from "+describeDate(r.From)+describePart(r.FromPart))
+	}
+
+	if r.To != nil {
+		word := "to "
+		if r.Back {
+			word = "back "
+		}
+
+		parts = append(parts, word+describeDate(r.To)+describePart(r.ToPart))
+	}
+
+	if d := r.Duration; d != nil {
+		business := ""
+		if d.BusinessDays {
+			business = " working"
+		}
+
+		parts = append(parts, fmt.Sprintf("for %d%s %s", d.Amount, business, d.Unit))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func describeDate(d Date) string {
+	switch d := d.(type) {
+	case *Keyword:
+		return d.Word
+	case *Calendar:
+		return fmt.Sprintf("%04d-%02d-%02d", d.Year, d.Month, d.Day)
+	case *Weekday:
+		return strings.TrimSpace(d.Modifier + " " + strings.ToLower(d.Day.String()))
+	case *Relative:
+		return fmt.Sprintf("in %d %s", d.Amount, d.Unit)
+	case *EndOf:
+		if d.Next {
+			return "end of next " + d.Unit.String()
+		}
+
+		return "end of " + d.Unit.String()
+	}
+
+	return "?"
+}
+
+func describePart(part DayPart) string {
+	switch part {
+	case Morning:
+		return " morning"
+	case Afternoon:
+		return " afternoon"
+	}
+
+	return ""
+}
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		input string
+		want  string
+	}{
+		{"tomorrow", "from tomorrow"},
+		{"from today until friday", "from today to friday"},
+		{"from 20/02/2017 until 24/02/2017", "from 2017-02-20 to 2017-02-24"},
+		{"2017-02-20 - 2017-02-24", "from 2017-02-20 to 2017-02-24"},
+		{"until February 20th, 2018", "to 2018-02-20"},
+		{"from the 3rd of march", "from 0000-03-03"},
+		{"20-24 feb", "from 0000-02-20 to 0000-02-24"},
+		{"between monday and wednesday", "from monday to wednesday"},
+		{"since yesterday", "from yesterday"},
+		{"back on next tuesday", "back next tuesday"},
+		{"back on tuesday afternoon", "back tuesday afternoon"},
+		{"tomorrow afternoon", "from tomorrow afternoon"},
+		{"this afternoon", "from today afternoon"},
+		{"from monday in the afternoon until wednesday morning", "from monday afternoon to wednesday morning"},
+		{"for 3 working days", "for 3 working day"},
+		{"from monday for 2 weeks", "from monday for 2 week"},
+		{"for a week", "for 1 week"},
+		{"until end of next month", "to end of next month"},
+		{"until end of the week", "to end of week"},
+		{"in 3 days", "from in 3 day"},
+		{"next week", "from in 1 week"},
+		{"I'm on vacation until next friday", "to next friday"},
+		{"on sick leave from monday", "from monday"},
+		{"5 people are out from monday", "from monday"},
+		{"from 1.3. until 5.3.", "from 0000-03-01 to 0000-03-05"},
+		{"between 1.3. and 5.3.", "from 0000-03-01 to 0000-03-05"},
+		{"from 1.3.2018. until 5.3.2018.", "from 2018-03-01 to 2018-03-05"},
+	}
+
+	for _, c := range cases {
+		r, err := Parse(c.input)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", c.input, err)
+			continue
+		}
+
+		if got := describe(r); got != c.want {
+			t.Errorf("Parse(%q) = %q, want %q", c.input, got, c.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	cases := []struct {
+		input string
+		token string
+	}{
+		{"", ""},
+		{"vacation", ""},
+		{"from tomorow", "tomorow"},
+		{"until 20", ""},
+		{"for 0 days", "0"},
+		{"in 99999 days", "99999"},
+		{"between monday or tuesday", "or"},
+		{"from monday until someday, then from friday", "someday"},
+	}
+
+	for _, c := range cases {
+		r, err := Parse(c.input)
+		if err == nil {
+			t.Errorf("Parse(%q) = %q, want an error", c.input, describe(r))
+			continue
+		}
+
+		perr, ok := err.(*ParseError)
+		if !ok {
+			t.Errorf("Parse(%q) returned %T, want *ParseError", c.input, err)
+			continue
+		}
+
+		if perr.Token != c.token {
+			t.Errorf("Parse(%q) failed near %q, want %q", c.input, perr.Token, c.token)
+		}
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	for input, want := range map[string]bool{
+		"friday":                 true,
+		"until friday":           true,
+		"back on 11/3/2030":      true,
+		"for 2 days":             true,
+		"until friday I guess":   false,
+		"the release is friday":  false,
+		"let's cancel the event": false,
+	} {
+		if _, err := ParseRange(input); (err == nil) != want {
+			t.Errorf("ParseRange(%q) returned %v", input, err)
+		}
+	}
+}
+
+func TestParseIsBounded(t *testing.T) {
+	for _, input := range []string{
+		strings.Repeat("on ", 10000),
+		strings.Repeat("from ", 10000),
+		strings.Repeat("1 ", 10000),
+		strings.Repeat("in the ", 10000),
+	} {
+		start := time.Now()
+		Parse(input)
+		if elapsed := time.Since(start); elapsed > 100*time.Millisecond {
+			t.Errorf("Parse(%.12q...) took %s", input, elapsed)
+		}
+	}
+
+	// dates after maxTokens words are not looked at
+	if _, err := Parse(strings.Repeat("word ", maxTokens) + "tomorrow"); err == nil {
+		t.Error("Parse found a date past maxTokens")
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	for _, seed := range []string{
+		"from 20/02/2017 until 24/02/2017",
+		"back on tuesday afternoon",
+		"between monday and wednesday",
+		"for 3 working days",
+		"20-24 feb",
+		"on on on",
+		"until end of next month",
+		"from 1.3. until 5.3.",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, input string) {
+		r, err := Parse(input)
+		if err != nil {
+			if _, ok := err.(*ParseError); !ok {
+				t.Fatalf("Parse(%q) returned %T, want *ParseError", input, err)
+			}
+
+			return
+		}
+
+		if r.From == nil && r.To == nil && r.Duration == nil {
+			t.Fatalf("Parse(%q) returned an empty range", input)
+		}
+
+		for _, d := range []Date{r.From, r.To} {
+			if c, ok := d.(*Calendar); ok && c.Month == 0 {
+				t.Fatalf("Parse(%q) left a bare day in the range", input)
+			}
+		}
+	})
+}
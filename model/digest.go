@@ -0,0 +1,45 @@
+package model
+
+import (
+	"time"
+
+	htime "github.com/italolelis/hellowork/time"
+)
+
+// Digest is the summary of who is out posted to a channel every working
+// day
+type Digest struct {
+	Channel string
+	// Hour and Minute are when to post it, in Location
+	Hour     int
+	Minute   int
+	Location string
+	// Language is the one of the catalogue it's written with
+	Language string
+	// LastSent is when it was last posted, or when it was set up, so it's
+	// never posted twice for the same day
+	LastSent time.Time
+}
+
+// TimeLocation returns the time zone of the digest, falling back to the
+// server's one when it's unknown
+func (d *Digest) TimeLocation() *time.Location {
+	return (&User{Location: d.Location}).TimeLocation()
+}
+
+// Next returns when the digest is posted next after the given time,
+// skipping the weekends
+func (d *Digest) Next(after time.Time) time.Time {
+	after = after.In(d.TimeLocation())
+	next := time.Date(after.Year(), after.Month(), after.Day(), d.Hour, d.Minute, 0, 0, after.Location())
+	for !next.After(after) || htime.IsWeekend(next) {
+		next = next.AddDate(0, 0, 1)
+	}
+
+	return next
+}
+
+// IsDue reports whether the digest should be posted at the given time
+func (d *Digest) IsDue(now time.Time) bool {
+	return !d.Next(d.LastSent).After(now)
+}
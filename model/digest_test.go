@@ -0,0 +1,72 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDigestNext(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	digest := &Digest{Hour: 9, Minute: 30, Location: "Europe/Berlin"}
+	cases := []struct {
+		name  string
+		after time.Time
+		want  time.Time
+	}{
+		{"before the time on a weekday", time.Date(2018, time.March, 14, 8, 0, 0, 0, berlin), time.Date(2018, time.March, 14, 9, 30, 0, 0, berlin)},
+		{"at the time", time.Date(2018, time.March, 14, 9, 30, 0, 0, berlin), time.Date(2018, time.March, 15, 9, 30, 0, 0, berlin)},
+		{"after the time", time.Date(2018, time.March, 14, 12, 0, 0, 0, berlin), time.Date(2018, time.March, 15, 9, 30, 0, 0, berlin)},
+		{"friday after the time", time.Date(2018, time.March, 16, 12, 0, 0, 0, berlin), time.Date(2018, time.March, 19, 9, 30, 0, 0, berlin)},
+		{"friday before the time", time.Date(2018, time.March, 16, 7, 0, 0, 0, berlin), time.Date(2018, time.March, 16, 9, 30, 0, 0, berlin)},
+		{"saturday", time.Date(2018, time.March, 17, 7, 0, 0, 0, berlin), time.Date(2018, time.March, 19, 9, 30, 0, 0, berlin)},
+		{"sunday", time.Date(2018, time.March, 18, 23, 0, 0, 0, berlin), time.Date(2018, time.March, 19, 9, 30, 0, 0, berlin)},
+		{"in another zone", time.Date(2018, time.March, 14, 7, 0, 0, 0, time.UTC), time.Date(2018, time.March, 14, 9, 30, 0, 0, berlin)},
+		{"in another zone on the next day", time.Date(2018, time.March, 16, 23, 30, 0, 0, time.UTC), time.Date(2018, time.March, 19, 9, 30, 0, 0, berlin)},
+		// the clocks go forward on the 25th and back on the 28th of October
+		{"over the start of summer time", time.Date(2018, time.March, 23, 12, 0, 0, 0, berlin), time.Date(2018, time.March, 26, 9, 30, 0, 0, berlin)},
+		{"over the end of summer time", time.Date(2018, time.October, 26, 12, 0, 0, 0, berlin), time.Date(2018, time.October, 29, 9, 30, 0, 0, berlin)},
+	}
+
+	for _, c := range cases {
+		got := digest.Next(c.after)
+		if !got.Equal(c.want) {
+			t.Errorf("%s: got %s, want %s", c.name, got, c.want)
+		}
+
+		if got.Location().String() != "Europe/Berlin" {
+			t.Errorf("%s: got it in %s", c.name, got.Location())
+		}
+	}
+}
+
+func TestDigestIsDue(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name     string
+		lastSent time.Time
+		now      time.Time
+		due      bool
+	}{
+		{"set up before the time", time.Date(2018, time.March, 14, 8, 0, 0, 0, berlin), time.Date(2018, time.March, 14, 9, 0, 0, 0, berlin), false},
+		{"at the time", time.Date(2018, time.March, 14, 8, 0, 0, 0, berlin), time.Date(2018, time.March, 14, 9, 30, 0, 0, berlin), true},
+		{"already sent today", time.Date(2018, time.March, 14, 9, 30, 0, 0, berlin), time.Date(2018, time.March, 14, 17, 0, 0, 0, berlin), false},
+		{"sent on friday, on the weekend", time.Date(2018, time.March, 16, 9, 30, 0, 0, berlin), time.Date(2018, time.March, 18, 9, 30, 0, 0, berlin), false},
+		{"sent on friday, on monday", time.Date(2018, time.March, 16, 9, 30, 0, 0, berlin), time.Date(2018, time.March, 19, 9, 30, 0, 0, berlin), true},
+		{"missed days ago", time.Date(2018, time.March, 12, 9, 30, 0, 0, berlin), time.Date(2018, time.March, 14, 8, 0, 0, 0, berlin), true},
+	}
+
+	for _, c := range cases {
+		digest := &Digest{Hour: 9, Minute: 30, Location: "Europe/Berlin", LastSent: c.lastSent}
+		if due := digest.IsDue(c.now); due != c.due {
+			t.Errorf("%s: due is %t, want %t", c.name, due, c.due)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/italolelis/hellowork/model/dateparse"
+	htime "github.com/italolelis/hellowork/time"
+)
+
+// Duration represents the length of an absence, counted either in calendar
+// days or in business days
+type Duration struct {
+	Amount       int
+	Unit         dateparse.Unit
+	BusinessDays bool
+}
+
+// ParseDuration converts an expression like "2 weeks" or "3 working days"
+// into a Duration
+func ParseDuration(expr string) (*Duration, error) {
+	d, err := dateparse.ParseDuration(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	return newDuration(d), nil
+}
+
+func newDuration(d *dateparse.Duration) *Duration {
+	return &Duration{Amount: d.Amount, Unit: d.Unit, BusinessDays: d.BusinessDays}
+}
+
+// EndFrom returns the end of the last day covered by the duration when the
+// absence starts on from. Business day durations skip weekends and start
+// counting on the first weekday
+func (d *Duration) EndFrom(from time.Time) time.Time {
+	if d.BusinessDays && d.Unit != dateparse.Month {
+		days := d.Amount
+		if d.Unit == dateparse.Week {
+			days *= 5
+		}
+
+		if htime.IsWeekend(from) {
+			from = htime.NextWeekday(from)
+		}
+
+		return htime.EndOfDay(htime.AddWeekdays(from, days-1))
+	}
+
+	return htime.EndOfDay(htime.SubDay(addUnits(from, d.Amount, d.Unit)))
+}
+
+func (d *Duration) String() string {
+	unit := d.Unit.String()
+	if d.BusinessDays {
+		unit = "working " + unit
+	}
+
+	if d.Amount > 1 {
+		unit += "s"
+	}
+
+	return fmt.Sprintf("%d %s", d.Amount, unit)
+}
@@ -0,0 +1,172 @@
+package model
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+var (
+	ErrStatusNotFound = errors.New("status not found")
+)
+
+type EventType string
+
+const (
+	StatusCreated   EventType = "status_created"
+	StatusEdited    EventType = "status_edited"
+	StatusCancelled EventType = "status_cancelled"
+	ReturnedEarly   EventType = "returned_early"
+	StatusAnnounced EventType = "status_announced"
+)
+
+// Event is a change made to one of the statuses of a user. Events are only
+// ever appended, the statuses of a user are their projection
+type Event struct {
+	Type EventType
+	User UserID
+	// Author is who made the change, which isn't always the user
+	Author UserID
+	At     time.Time
+	// Status is the status as it was left by the change, or as it was before
+	// being cancelled
+	Status Status
+}
+
+type StatusID string
+
+// NewStatusID returns a random ID that stays the same for the whole life of
+// a status
+func NewStatusID() StatusID {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); nil != err {
+		panic(err)
+	}
+
+	return StatusID(hex.EncodeToString(b))
+}
+
+// AddStatus creates a new status for the user
+func (u *User) AddStatus(status *Status, author UserID) {
+	if len(status.ID) == 0 {
+		status.ID = NewStatusID()
+	}
+
+	u.record(StatusCreated, author, status)
+}
+
+// EditStatus replaces the status with the same ID
+func (u *User) EditStatus(status *Status, author UserID) error {
+	if nil == u.FindStatus(status.ID) {
+		return ErrStatusNotFound
+	}
+
+	u.record(StatusEdited, author, status)
+	return nil
+}
+
+// CancelStatus removes the status as if it never happened
+func (u *User) CancelStatus(id StatusID, author UserID) error {
+	status := u.FindStatus(id)
+	if nil == status {
+		return ErrStatusNotFound
+	}
+
+	u.record(StatusCancelled, author, status)
+	return nil
+}
+
+// ReturnEarly ends the status at the given time
+func (u *User) ReturnEarly(id StatusID, at time.Time, author UserID) error {
+	status := u.FindStatus(id)
+	if nil == status {
+		return ErrStatusNotFound
+	}
+
+	ended := *status
+	ended.To = at
+	u.record(ReturnedEarly, author, &ended)
+	return nil
+}
+
+// AnnounceStatus remembers that a channel was told about the status, the
+// author being who asked about it there
+func (u *User) AnnounceStatus(id StatusID, channel string, author UserID) error {
+	status := u.FindStatus(id)
+	if nil == status {
+		return ErrStatusNotFound
+	}
+
+	if status.WasAnnouncedIn(channel) {
+		return nil
+	}
+
+	announced := *status
+	announced.Channels = append(append([]string(nil), status.Channels...), channel)
+	u.record(StatusAnnounced, author, &announced)
+	return nil
+}
+
+// FindStatus returns the status with the given ID, or nil
+func (u *User) FindStatus(id StatusID) *Status {
+	if i := u.statusIndex(id); i >= 0 {
+		return u.Statuses[i]
+	}
+
+	return nil
+}
+
+// Changes returns the events recorded since the user was loaded, which the
+// repository appends to the history when saving the user
+func (u *User) Changes() []*Event {
+	return u.changes
+}
+
+// ClearChanges forgets the recorded events once they are saved
+func (u *User) ClearChanges() {
+	u.changes = nil
+}
+
+// Replay rebuilds the statuses of the user from its whole history
+func (u *User) Replay(events []*Event) {
+	u.Statuses = make([]*Status, 0)
+	for _, event := range events {
+		u.apply(event)
+	}
+}
+
+func (u *User) record(t EventType, author UserID, status *Status) {
+	event := &Event{Type: t, User: u.ID, Author: author, At: time.Now(), Status: *status}
+	u.apply(event)
+	u.changes = append(u.changes, event)
+}
+
+// apply projects the event on the statuses of the user
+func (u *User) apply(event *Event) {
+	status := event.Status
+	i := u.statusIndex(status.ID)
+
+	switch event.Type {
+	case StatusCreated:
+		u.Statuses = append(u.Statuses, &status)
+	case StatusEdited, ReturnedEarly, StatusAnnounced:
+		if i >= 0 {
+			u.Statuses[i] = &status
+		}
+	case StatusCancelled:
+		if i >= 0 {
+			u.Statuses = append(u.Statuses[:i:i], u.Statuses[i+1:]...)
+		}
+	}
+}
+
+func (u *User) statusIndex(id StatusID) int {
+	for i, status := range u.Statuses {
+		if status.ID == id {
+			return i
+		}
+	}
+
+	return -1
+}
@@ -1,7 +1,7 @@
 package model
 
 import (
-	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -16,26 +16,23 @@ var (
 	WorkTrip    Reason = "work trip"
 )
 
+// precedences ranks the reasons for when statuses overlap, the highest one
+// wins: being sick overrides working remote or a work trip
+var precedences = map[Reason]int{
+	Remote:      1,
+	OutOfOffice: 2,
+	WorkTrip:    3,
+	Vacation:    4,
+	Sick:        5,
+}
+
 type UserID string
 type Reason string
 
-func ParseTime(date string) time.Time {
-	now := time.Now()
-	switch strings.ToLower(date) {
-	case "today":
-		return htime.Today()
-	case "tomorrow":
-		return htime.Tomorrow()
-	case "yesterday":
-		return htime.Yesterday()
-	case "next week":
-		return htime.AddWeek(now)
-	case "next month":
-	default:
-		return now
-	}
-
-	return now
+// Overrides reports whether the reason wins over the other one when both
+// statuses cover the same time
+func (r Reason) Overrides(other Reason) bool {
+	return precedences[r] > precedences[other]
 }
 
 func ParseReason(reason string) Reason {
@@ -58,50 +55,191 @@ func ParseReason(reason string) Reason {
 type User struct {
 	ID       UserID
 	Username string
+	// Location is the IANA time zone of the user, such as "America/Sao_Paulo"
+	Location string
 	Statuses []*Status
+	// Version is bumped by the repository on every save to detect
+	// concurrent changes
+	Version int
+	// Profile is set when the user wants their statuses on their slack
+	// profile
+	Profile *ProfileSync
+	changes []*Event
 }
 
 func NewUser(id UserID) *User {
 	return &User{ID: id, Statuses: make([]*Status, 0)}
 }
 
-func (u *User) AddStatus(status *Status) {
-	u.Statuses = append(u.Statuses, status)
+// Copy returns a deep copy of the user, which can be changed without
+// touching the original
+func (u *User) Copy() *User {
+	user := *u
+	user.Statuses = make([]*Status, len(u.Statuses))
+	for i, status := range u.Statuses {
+		copied := *status
+		copied.Channels = append([]string(nil), status.Channels...)
+		user.Statuses[i] = &copied
+	}
+
+	if nil != u.Profile {
+		profile := *u.Profile
+		user.Profile = &profile
+	}
+
+	user.changes = append([]*Event(nil), u.changes...)
+
+	return &user
 }
 
+// GetStatus returns the status the user is on right now or, when there is
+// none, the next one with a known end. It returns nil when the user has
+// nothing planned
 func (u *User) GetStatus() *Status {
-	return u.Statuses[len(u.Statuses) - 1]
+	return u.StatusFrom(u.Now())
 }
 
+// StatusFrom returns the status covering the date or, when there is none,
+// the next one with a known end
+func (u *User) StatusFrom(date time.Time) *Status {
+	if status := u.StatusAt(date); nil != status {
+		return status
+	}
+
+	for _, status := range u.Upcoming(date) {
+		if !status.To.IsZero() {
+			return status
+		}
+	}
+
+	return nil
+}
+
+// IsAvailable reports whether none of the statuses covers the date
 func (u *User) IsAvailable(date time.Time) bool {
-	var available bool
+	return nil == u.StatusAt(date)
+}
+
+// StatusAt returns the status covering the date, or nil. When several do,
+// the reason with the highest precedence wins and, between equal reasons,
+// the latest added
+func (u *User) StatusAt(date time.Time) *Status {
+	return u.StatusDuring(date, date)
+}
+
+// StatusDuring returns the status overlapping the period from and to, both
+// included, with the same precedence as StatusAt
+func (u *User) StatusDuring(from time.Time, to time.Time) *Status {
+	var found *Status
+	for _, status := range u.Statuses {
+		if !status.Overlaps(from, to) {
+			continue
+		}
+
+		if nil == found || !found.Reason.Overrides(status.Reason) {
+			found = status
+		}
+	}
+
+	return found
+}
+
+// Upcoming returns the statuses that haven't ended by the date, including
+// the ones still waiting for an until, sorted by when they start
+func (u *User) Upcoming(date time.Time) []*Status {
+	var statuses []*Status
 	for _, status := range u.Statuses {
-		available = !status.isValid(date)
+		if status.To.IsZero() || !status.To.Before(date) {
+			statuses = append(statuses, status)
+		}
 	}
 
-	return available
+	sort.SliceStable(statuses, func(i, j int) bool {
+		return statuses[i].From.Before(statuses[j].From)
+	})
+
+	return statuses
 }
 
-func (u *User) String() string {
-	from := u.GetStatus().From
-	to := u.GetStatus().To
-	return fmt.Sprintf("<@%s> is out from %s until %s (%s)", u.ID, from.Format("02/01/2006"), to.Format("Monday"), to.Format("02/01/2006"))
+// IsOutDuring reports whether any status of the user overlaps the period
+// from and to, both included
+func (u *User) IsOutDuring(from time.Time, to time.Time) bool {
+	for _, status := range u.Statuses {
+		if status.Overlaps(from, to) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// TimeLocation returns the time zone of the user, falling back to the
+// server's one when it's unknown
+func (u *User) TimeLocation() *time.Location {
+	if len(u.Location) == 0 {
+		return time.Local
+	}
+
+	loc, err := time.LoadLocation(u.Location)
+	if err != nil {
+		return time.Local
+	}
+
+	return loc
+}
+
+// Now returns the current time in the user's time zone
+func (u *User) Now() time.Time {
+	return nowIn(u.Location)
+}
+
+// nowIn returns the current time in the given IANA time zone, or in the
+// server's one when the zone is empty or unknown
+func nowIn(location string) time.Time {
+	if len(location) == 0 {
+		return time.Now()
+	}
+
+	now, _ := htime.NowInLocation(location)
+	return now
+}
+
+// SameZone reports whether t has the same UTC offset in loc
+func SameZone(t time.Time, loc *time.Location) bool {
+	_, offset := t.Zone()
+	_, other := t.In(loc).Zone()
+
+	return offset == other
 }
 
 type Status struct {
+	ID          StatusID
 	Description string
 	From        time.Time
 	To          time.Time
 	Reason      Reason
+	// Channels were told about the status, so they can be told when it
+	// changes
+	Channels []string
 }
 
 func NewStatus(description string, from time.Time, to time.Time, reason Reason) *Status {
-	return &Status{description, from, to, reason}
+	return &Status{Description: description, From: from, To: to, Reason: reason}
 }
 
-func (s *Status) isValid(date time.Time) bool {
-	before := s.From.Before(date)
-	after := s.To.After(date)
+// WasAnnouncedIn reports whether the channel was told about the status
+func (s *Status) WasAnnouncedIn(channel string) bool {
+	for _, c := range s.Channels {
+		if c == channel {
+			return true
+		}
+	}
+
+	return false
+}
 
-	return !before && !after
+// Overlaps reports whether the status covers any time between from and to,
+// both included. A status still waiting for an until covers nothing
+func (s *Status) Overlaps(from time.Time, to time.Time) bool {
+	return !s.To.Before(from) && !s.From.After(to)
 }
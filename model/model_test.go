@@ -0,0 +1,136 @@
+package model
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+	"time"
+)
+
+var (
+	reasons      = []Reason{Remote, OutOfOffice, WorkTrip, Vacation, Sick}
+	propertyBase = time.Date(2017, time.December, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// randomUser is a user with a few statuses of any reason in the ten days
+// after propertyBase, some still waiting for an until, and a time in those
+// days to look at them
+type randomUser struct {
+	*User
+	Date time.Time
+}
+
+func (randomUser) Generate(r *rand.Rand, size int) reflect.Value {
+	user := NewUser("U1")
+	for i := r.Intn(6); i > 0; i-- {
+		from := propertyBase.Add(time.Duration(r.Intn(240)) * time.Hour)
+		to := from.Add(time.Duration(r.Intn(100)) * time.Hour)
+		if r.Intn(5) == 0 {
+			to = time.Time{}
+		}
+
+		user.AddStatus(NewStatus("", from, to, reasons[r.Intn(len(reasons))]), "U1")
+	}
+
+	date := propertyBase.Add(time.Duration(r.Intn(300)) * time.Hour)
+	return reflect.ValueOf(randomUser{user, date})
+}
+
+func checkProperty(t *testing.T, f interface{}) {
+	if err := quick.Check(f, &quick.Config{MaxCount: 2000}); nil != err {
+		t.Error(err)
+	}
+}
+
+func TestStatusAtWinsOverEveryCoveringStatus(t *testing.T) {
+	checkProperty(t, func(u randomUser) bool {
+		found := u.StatusAt(u.Date)
+		for i, status := range u.Statuses {
+			if !status.Overlaps(u.Date, u.Date) {
+				continue
+			}
+
+			if nil == found || status.Reason.Overrides(found.Reason) {
+				return false
+			}
+
+			// between equal reasons the latest added wins
+			if status.Reason == found.Reason && u.statusIndex(found.ID) < i {
+				return false
+			}
+		}
+
+		return nil == found || found.Overlaps(u.Date, u.Date)
+	})
+}
+
+func TestAvailableUnlessOut(t *testing.T) {
+	checkProperty(t, func(u randomUser) bool {
+		return u.IsAvailable(u.Date) != u.IsOutDuring(u.Date, u.Date) &&
+			u.IsAvailable(u.Date) == (nil == u.StatusAt(u.Date))
+	})
+}
+
+func TestOutDuringLongerPeriods(t *testing.T) {
+	checkProperty(t, func(u randomUser, before uint8, after uint8) bool {
+		from := u.Date.Add(-time.Duration(before) * time.Hour)
+		to := u.Date.Add(time.Duration(after) * time.Hour)
+
+		return !u.IsOutDuring(u.Date, u.Date) || u.IsOutDuring(from, to) && nil != u.StatusDuring(from, to)
+	})
+}
+
+func TestUpcomingIsSortedAndNotOver(t *testing.T) {
+	checkProperty(t, func(u randomUser) bool {
+		upcoming := u.Upcoming(u.Date)
+		for i, status := range upcoming {
+			if !status.To.IsZero() && status.To.Before(u.Date) {
+				return false
+			}
+
+			if i > 0 && status.From.Before(upcoming[i-1].From) {
+				return false
+			}
+		}
+
+		return true
+	})
+}
+
+func TestReplayRebuildsTheStatuses(t *testing.T) {
+	checkProperty(t, func(u randomUser, edits []uint8) bool {
+		for _, edit := range edits {
+			if len(u.Statuses) == 0 {
+				break
+			}
+
+			status := *u.Statuses[int(edit)%len(u.Statuses)]
+			if edit%3 == 0 {
+				u.CancelStatus(status.ID, "U2")
+				continue
+			}
+
+			status.Reason = reasons[int(edit)%len(reasons)]
+			u.EditStatus(&status, "U2")
+		}
+
+		replayed := NewUser(u.ID)
+		replayed.Replay(u.Changes())
+
+		return reflect.DeepEqual(replayed.Statuses, u.Statuses)
+	})
+}
+
+func TestCopyIsIndependent(t *testing.T) {
+	checkProperty(t, func(u randomUser) bool {
+		before := u.Copy()
+		copied := u.Copy()
+		for _, status := range copied.Statuses {
+			status.Reason = Sick
+			status.Channels = append(status.Channels, "C1")
+		}
+
+		return reflect.DeepEqual(before.Statuses, u.Statuses)
+	})
+}
@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+// ProfileSync is what hellowork needs to show the statuses of a user on
+// their slack profile
+type ProfileSync struct {
+	// Token is the user token allowed to change the profile. It's stored in
+	// plain text along with the statuses, whoever can read the storage can
+	// act as the user in slack with the scopes it was given
+	Token string
+	// Status is the status shown on the profile and Until when it was set to
+	// end, Status is empty while the profile shows what the user had before
+	Status StatusID
+	Until  time.Time
+	// Text and Emoji are what the profile showed before hellowork changed it,
+	// to put them back once the status is over
+	Text  string
+	Emoji string
+}
+
+// IsShowing reports whether the profile shows the status as it is now
+func (p *ProfileSync) IsShowing(status *Status) bool {
+	if nil == status {
+		return len(p.Status) == 0
+	}
+
+	return p.Status == status.ID && p.Until.Equal(status.To)
+}
@@ -1,65 +1,163 @@
 package model
 
 import (
-	"regexp"
 	"time"
-	"errors"
-)
-
-var (
-	timablePattern     = regexp.MustCompile(`(?i)(from|since)\s+(today|yesterday|tomorrow|next week|next month)\s+(until|till|to)\s+(today|yesterday|tomorrow|next week|next month)`)
-	fromTimablePattern = regexp.MustCompile(`(?i)(from|since)\s+(today|yesterday|tomorrow|next week|next month)`)
-	toTimablePattern   = regexp.MustCompile(`(?i)(until|till|to)\s+(today|yesterday|tomorrow|next week|next month)`)
-	defaultPattern     = regexp.MustCompile(`(?i)(today|yesterday|tomorrow|next week|next month)`)
-)
 
-var (
-	ErrPatternDoestMatch = errors.New("couldn't match a pattern")
+	"github.com/italolelis/hellowork/model/dateparse"
+	htime "github.com/italolelis/hellowork/time"
 )
 
 type TimableMention struct {
-	From    time.Time
-	HasFrom bool
-	To      time.Time
-	HasTo   bool
+	From     time.Time
+	HasFrom  bool
+	To       time.Time
+	HasTo    bool
+	Duration *Duration
+}
+
+// NewTimableMention finds the absence range described in a message and
+// resolves it in the given IANA time zone, or the server's one when it's
+// empty or unknown. Errors from the grammar are returned as
+// *dateparse.ParseError so callers can point at the word they didn't understand
+func NewTimableMention(msg string, location string) (*TimableMention, error) {
+	r, err := dateparse.Parse(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	return newTimableMention(r, nowIn(location))
 }
 
-func NewTimableMention(msg string) (*TimableMention, error) {
-	var results [][]string
+func newTimableMention(r *dateparse.Range, now time.Time) (*TimableMention, error) {
+	t := &TimableMention{From: now, HasFrom: true}
+
+	var err error
+	if r.From != nil {
+		if t.From, err = resolveDate(r.From, now); err != nil {
+			return nil, err
+		}
+	}
+
+	// statuses cover whole days, or half days when a part of the day is given
+	t.From = startOfPart(t.From, r.FromPart)
+
 	switch {
-	case timablePattern.MatchString(msg):
-		results = timablePattern.FindAllStringSubmatch(msg, -1)
-		return &TimableMention{
-			From:    ParseTime(results[0][0]),
-			HasFrom: len(results[0][0]) > 0,
-			To:      ParseTime(results[1][0]),
-			HasTo:   len(results[1][0]) > 0,
-		}, nil
-	case fromTimablePattern.MatchString(msg):
-		results = fromTimablePattern.FindAllStringSubmatch(msg, -1)
-		return &TimableMention{
-			From:    ParseTime(results[0][2]),
-			HasFrom: true,
-			HasTo:   false,
-		}, nil
-	case toTimablePattern.MatchString(msg):
-		results = toTimablePattern.FindAllStringSubmatch(msg, -1)
-		return &TimableMention{
-			From:    time.Now(),
-			HasFrom: true,
-			To:      ParseTime(results[0][2]),
-			HasTo:   true,
-		}, nil
-	case defaultPattern.MatchString(msg):
-		results = defaultPattern.FindAllStringSubmatch(msg, -1)
-		return &TimableMention{
-			From:    ParseTime(results[0][0]),
-			HasFrom: true,
-			HasTo:   false,
-		}, nil
+	case r.Duration != nil:
+		t.Duration = newDuration(r.Duration)
+		t.To = t.Duration.EndFrom(t.From)
+		t.HasTo = true
+	case r.To != nil:
+		if t.To, err = resolveTo(r, t.From, now); err != nil {
+			return nil, err
+		}
+
+		t.HasTo = true
+	case r.FromPart != dateparse.WholeDay:
+		// a half day on its own, as in "tomorrow afternoon"
+		t.To = endOfPart(t.From, r.FromPart)
+		t.HasTo = true
+	}
+
+	if t.HasTo && t.To.Before(t.From) {
+		return nil, ErrInvalidPeriod
+	}
+
+	if t.From.After(LatestDate) || t.To.After(LatestDate) {
+		return nil, ErrTooFarAhead
+	}
+
+	return t, nil
+}
+
+// NewEndMention resolves the answer to when an absence starting at from
+// ends, such as "until friday", "back on monday" or "for 3 days". A bare
+// date, as in "friday", could be the last day away as well as the day of
+// the return, so it's taken as the last day away and reported as ambiguous
+func NewEndMention(msg string, from time.Time) (to time.Time, ambiguous bool, err error) {
+	r, err := dateparse.Parse(msg)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	to, ambiguous, err = newEndMention(r, from, time.Now().In(from.Location()))
+	if nil == err && to.After(LatestDate) {
+		return time.Time{}, false, ErrTooFarAhead
+	}
+
+	return to, ambiguous, err
+}
+
+func newEndMention(r *dateparse.Range, from time.Time, now time.Time) (to time.Time, ambiguous bool, err error) {
+	switch {
+	case r.Duration != nil:
+		return newDuration(r.Duration).EndFrom(from), false, nil
+	case r.To != nil:
+		to, err = resolveTo(r, from, now)
+		return to, false, err
+	case r.From != nil:
+		to, err = resolveEnd(r.From, from, now)
+		return endOfPart(to, r.FromPart), true, err
+	}
+
+	return time.Time{}, false, ErrNoEnd
+}
+
+// resolveTo resolves the end of a range starting at from. Being back on a
+// day means being away until the end of the day before, or until the end of
+// the morning when back in the afternoon
+func resolveTo(r *dateparse.Range, from time.Time, now time.Time) (time.Time, error) {
+	to, err := resolveEnd(r.To, from, now)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	switch {
+	case r.Back && r.ToPart == dateparse.Afternoon:
+		return endOfPart(to, dateparse.Morning), nil
+	case r.Back:
+		return htime.EndOfDay(htime.SubDay(to)), nil
+	}
+
+	return endOfPart(to, r.ToPart), nil
+}
+
+// startOfPart returns when the given part of the day starts
+func startOfPart(t time.Time, part dateparse.DayPart) time.Time {
+	if part == dateparse.Afternoon {
+		return htime.Midday(t)
+	}
+
+	return htime.StartOfDay(t)
+}
+
+// endOfPart returns the last instant of the given part of the day
+func endOfPart(t time.Time, part dateparse.DayPart) time.Time {
+	if part == dateparse.Morning {
+		return htime.Midday(t).Add(-time.Nanosecond)
+	}
+
+	return htime.EndOfDay(t)
+}
+
+// resolveEnd resolves the end of a range. Bare weekdays and dates without a
+// year are taken as the first occurrence after from, so "monday-friday" said
+// on a wednesday spans the next week
+func resolveEnd(d dateparse.Date, from time.Time, now time.Time) (time.Time, error) {
+	switch d := d.(type) {
+	case *dateparse.Weekday:
+		if d.Modifier == "" || d.Modifier == "on" {
+			return resolveDate(d, from)
+		}
+	case *dateparse.Calendar:
+		to, err := resolveDate(d, now)
+		if err == nil && d.Year == 0 && to.Before(htime.StartOfDay(from)) {
+			return newDate(to.Year()+1, d.Month, d.Day, now)
+		}
+
+		return to, err
 	}
 
-	return nil, ErrPatternDoestMatch
+	return resolveDate(d, now)
 }
 
 func (t *TimableMention) HasOnlyFrom() bool {
@@ -0,0 +1,55 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/italolelis/hellowork/model/dateparse"
+)
+
+func TestTimableMentionRejectsEndBeforeStart(t *testing.T) {
+	now := time.Date(2017, 2, 14, 10, 0, 0, 0, time.UTC)
+	for _, input := range []string{"until yesterday", "until 2017-02-13", "from 2017-02-20 until 2017-02-15", "back on 2017-02-14"} {
+		r, err := dateparse.Parse(input)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", input, err)
+		}
+
+		if _, err := newTimableMention(r, now); err != ErrInvalidPeriod {
+			t.Errorf("%q: got %v, want ErrInvalidPeriod", input, err)
+		}
+	}
+
+	for _, input := range []string{"until today", "this afternoon", "back tomorrow"} {
+		r, err := dateparse.Parse(input)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", input, err)
+		}
+
+		if _, err := newTimableMention(r, now); err != nil {
+			t.Errorf("%q: %v", input, err)
+		}
+	}
+}
+
+func TestTimableMentionRejectsDatesTooFarAhead(t *testing.T) {
+	now := time.Date(2017, 2, 14, 10, 0, 0, 0, time.UTC)
+	for _, input := range []string{"until 3000-01-01", "from 2300-01-01 until 2300-01-05", "on 9999-12-31"} {
+		r, err := dateparse.Parse(input)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", input, err)
+		}
+
+		if _, err := newTimableMention(r, now); err != ErrTooFarAhead {
+			t.Errorf("%q: got %v, want ErrTooFarAhead", input, err)
+		}
+	}
+
+	if _, _, err := NewEndMention("until 3000-01-01", now); err != ErrTooFarAhead {
+		t.Errorf("NewEndMention: got %v, want ErrTooFarAhead", err)
+	}
+
+	if _, err := ParseTime("3000-01-01", "UTC"); err != ErrTooFarAhead {
+		t.Errorf("ParseTime: got %v, want ErrTooFarAhead", err)
+	}
+}
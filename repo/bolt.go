@@ -0,0 +1,568 @@
+package repo
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"time"
+
+	bolt "github.com/coreos/bbolt"
+	"github.com/italolelis/hellowork/model"
+)
+
+var (
+	// usersBucket maps user IDs to their JSON encoded model.User
+	usersBucket = []byte("users")
+	// outBucket indexes who is out on each day, its keys are the UTC day
+	// followed by the user ID, such as "20171213/U1"
+	outBucket = []byte("out")
+	// longBucket lists the users with a status longer than maxIndexedDays,
+	// which are checked on every query instead of being indexed day by day
+	longBucket = []byte("long")
+	// eventsBucket is the history of every user, its keys are the user ID
+	// followed by a big endian sequence so they sort in order
+	eventsBucket = []byte("events")
+	// digestsBucket maps channels to their JSON encoded model.Digest
+	digestsBucket = []byte("digests")
+)
+
+const (
+	dayKeyLayout = "20060102"
+	// maxIndexedDays is the longest status indexed day by day, so a status
+	// "until 9999" doesn't write millions of keys
+	maxIndexedDays = 366
+)
+
+// Bolt is a Repository backed by an embedded bbolt file. Every write is a
+// single transaction synced to disk before it returns, so a crash never
+// leaves half a user behind
+type Bolt struct {
+	db *bolt.DB
+}
+
+// NewBolt opens the bbolt file at path, creating it when needed
+func NewBolt(path string) (*Bolt, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if nil != err {
+		return nil, err
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		// files written before the long statuses had their own bucket have
+		// every day of them indexed
+		reindex := nil == tx.Bucket(longBucket)
+		for _, name := range [][]byte{usersBucket, outBucket, longBucket, eventsBucket, digestsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); nil != err {
+				return err
+			}
+		}
+
+		if reindex {
+			return reindexBolt(tx)
+		}
+
+		return nil
+	})
+	if nil != err {
+		db.Close()
+		return nil, err
+	}
+
+	return &Bolt{db}, nil
+}
+
+// Close closes the bbolt file
+func (r *Bolt) Close() error {
+	return r.db.Close()
+}
+
+func (r *Bolt) Find(ctx context.Context, id string) (*model.User, error) {
+	if err := contextError(ctx); nil != err {
+		return nil, err
+	}
+
+	var user *model.User
+	err := r.db.View(func(tx *bolt.Tx) error {
+		var err error
+		user, err = getBoltUser(tx, []byte(id))
+		return err
+	})
+	if nil != err {
+		return nil, permanent(err)
+	}
+
+	if nil == user {
+		return nil, ErrNotFound
+	}
+
+	return user, nil
+}
+
+func (r *Bolt) FindAll(ctx context.Context) ([]*model.User, error) {
+	if err := contextError(ctx); nil != err {
+		return nil, err
+	}
+
+	var users []*model.User
+	err := r.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(usersBucket).ForEach(func(k, v []byte) error {
+			user, err := decodeBoltUser(v)
+			if nil != err {
+				return err
+			}
+
+			users = append(users, user)
+			return nil
+		})
+	})
+	if nil != err {
+		return nil, permanent(err)
+	}
+
+	return users, nil
+}
+
+func (r *Bolt) FindAllOut(ctx context.Context, date time.Time) ([]*model.User, error) {
+	return r.FindAllOutBetween(ctx, date, date)
+}
+
+// FindAllOutBetween only loads the users indexed on the days of the period,
+// then checks them against the exact times to honour half days
+func (r *Bolt) FindAllOutBetween(ctx context.Context, from time.Time, to time.Time) ([]*model.User, error) {
+	if err := contextError(ctx); nil != err {
+		return nil, err
+	}
+
+	var users []*model.User
+	err := r.db.View(func(tx *bolt.Tx) error {
+		seen := make(map[string]bool)
+		check := func(id []byte) error {
+			if seen[string(id)] {
+				return nil
+			}
+
+			seen[string(id)] = true
+			user, err := getBoltUser(tx, id)
+			if nil != err {
+				return err
+			}
+
+			if nil != user && user.IsOutDuring(from, to) {
+				users = append(users, user)
+			}
+
+			return nil
+		}
+
+		first := []byte(from.UTC().Format(dayKeyLayout) + "/")
+		last := []byte(to.UTC().Format(dayKeyLayout) + "/\xff")
+		c := tx.Bucket(outBucket).Cursor()
+		for k, _ := c.Seek(first); nil != k && bytes.Compare(k, last) <= 0; k, _ = c.Next() {
+			if err := check(k[len(first):]); nil != err {
+				return err
+			}
+		}
+
+		return tx.Bucket(longBucket).ForEach(func(id, _ []byte) error {
+			return check(id)
+		})
+	})
+	if nil != err {
+		return nil, permanent(err)
+	}
+
+	return users, nil
+}
+
+func (r *Bolt) FindAllByID(ctx context.Context, ids []string, date time.Time) ([]*model.User, error) {
+	var users []*model.User
+
+	for _, id := range ids {
+		user, err := r.Find(ctx, id)
+		switch {
+		case err == ErrNotFound:
+			continue
+		case nil != err:
+			return nil, err
+		}
+
+		if user.IsOutDuring(date, date) {
+			users = append(users, user)
+		}
+	}
+
+	return users, nil
+}
+
+// Add stores the user along with all of its statuses and reindexes the days
+// they cover
+func (r *Bolt) Add(ctx context.Context, user *model.User) error {
+	if err := contextError(ctx); nil != err {
+		return err
+	}
+
+	err := r.db.Update(func(tx *bolt.Tx) error {
+		id := []byte(user.ID)
+		stored, err := getBoltUser(tx, id)
+		if nil != err {
+			return err
+		}
+
+		var version int
+		if nil != stored {
+			version = stored.Version
+		}
+
+		if version != user.Version {
+			return ErrConflict
+		}
+
+		if err := unindexBoltUser(tx, stored); nil != err {
+			return err
+		}
+
+		saved := *user
+		saved.Version++
+		data, err := json.Marshal(&saved)
+		if nil != err {
+			return err
+		}
+
+		if err := tx.Bucket(usersBucket).Put(id, data); nil != err {
+			return err
+		}
+
+		if err := indexBoltUser(tx, user); nil != err {
+			return err
+		}
+
+		return appendBoltEvents(tx, user)
+	})
+
+	switch {
+	case err == ErrConflict:
+		return err
+	case nil != err:
+		return permanent(err)
+	}
+
+	user.ClearChanges()
+	user.Version++
+
+	return nil
+}
+
+func (r *Bolt) Remove(ctx context.Context, id string) error {
+	if err := contextError(ctx); nil != err {
+		return err
+	}
+
+	err := r.db.Update(func(tx *bolt.Tx) error {
+		stored, err := getBoltUser(tx, []byte(id))
+		if nil != err {
+			return err
+		}
+
+		if nil == stored {
+			return ErrNotFound
+		}
+
+		if err := unindexBoltUser(tx, stored); nil != err {
+			return err
+		}
+
+		return tx.Bucket(usersBucket).Delete([]byte(id))
+	})
+
+	switch {
+	case err == ErrNotFound:
+		return err
+	case nil != err:
+		return permanent(err)
+	}
+
+	return nil
+}
+
+func (r *Bolt) History(ctx context.Context, id string) ([]*model.Event, error) {
+	if err := contextError(ctx); nil != err {
+		return nil, err
+	}
+
+	var (
+		events []*model.Event
+		user   *model.User
+	)
+
+	err := r.db.View(func(tx *bolt.Tx) error {
+		var err error
+		if user, err = getBoltUser(tx, []byte(id)); nil != err {
+			return err
+		}
+
+		prefix := []byte(id + "/")
+		c := tx.Bucket(eventsBucket).Cursor()
+		for k, v := c.Seek(prefix); nil != k && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			var event model.Event
+			if err := json.Unmarshal(v, &event); nil != err {
+				return err
+			}
+
+			events = append(events, &event)
+		}
+
+		return nil
+	})
+	if nil != err {
+		return nil, permanent(err)
+	}
+
+	if nil == user && len(events) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return events, nil
+}
+
+func (r *Bolt) FindAllDigests(ctx context.Context) ([]*model.Digest, error) {
+	if err := contextError(ctx); nil != err {
+		return nil, err
+	}
+
+	var digests []*model.Digest
+	err := r.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(digestsBucket).ForEach(func(k, v []byte) error {
+			var digest model.Digest
+			if err := json.Unmarshal(v, &digest); nil != err {
+				return err
+			}
+
+			digests = append(digests, &digest)
+			return nil
+		})
+	})
+	if nil != err {
+		return nil, permanent(err)
+	}
+
+	return digests, nil
+}
+
+func (r *Bolt) AddDigest(ctx context.Context, digest *model.Digest) error {
+	if err := contextError(ctx); nil != err {
+		return err
+	}
+
+	err := r.db.Update(func(tx *bolt.Tx) error {
+		return putBoltDigest(tx, digest)
+	})
+	if nil != err {
+		return permanent(err)
+	}
+
+	return nil
+}
+
+func (r *Bolt) RemoveDigest(ctx context.Context, channel string) error {
+	return r.updateDigest(ctx, channel, func(tx *bolt.Tx, digest *model.Digest) error {
+		return tx.Bucket(digestsBucket).Delete([]byte(channel))
+	})
+}
+
+func (r *Bolt) DigestSent(ctx context.Context, channel string, at time.Time) error {
+	return r.updateDigest(ctx, channel, func(tx *bolt.Tx, digest *model.Digest) error {
+		digest.LastSent = at
+		return putBoltDigest(tx, digest)
+	})
+}
+
+// updateDigest changes the stored digest of the channel in a single
+// transaction, returning ErrNotFound when there is none
+func (r *Bolt) updateDigest(ctx context.Context, channel string, change func(*bolt.Tx, *model.Digest) error) error {
+	if err := contextError(ctx); nil != err {
+		return err
+	}
+
+	err := r.db.Update(func(tx *bolt.Tx) error {
+		data := tx.Bucket(digestsBucket).Get([]byte(channel))
+		if nil == data {
+			return ErrNotFound
+		}
+
+		var digest model.Digest
+		if err := json.Unmarshal(data, &digest); nil != err {
+			return err
+		}
+
+		return change(tx, &digest)
+	})
+
+	switch {
+	case err == ErrNotFound:
+		return err
+	case nil != err:
+		return permanent(err)
+	}
+
+	return nil
+}
+
+func putBoltDigest(tx *bolt.Tx, digest *model.Digest) error {
+	data, err := json.Marshal(digest)
+	if nil != err {
+		return err
+	}
+
+	return tx.Bucket(digestsBucket).Put([]byte(digest.Channel), data)
+}
+
+// appendBoltEvents adds the changes recorded on the user to its history
+func appendBoltEvents(tx *bolt.Tx, user *model.User) error {
+	bucket := tx.Bucket(eventsBucket)
+	for _, event := range user.Changes() {
+		seq, err := bucket.NextSequence()
+		if nil != err {
+			return err
+		}
+
+		data, err := json.Marshal(event)
+		if nil != err {
+			return err
+		}
+
+		key := make([]byte, len(user.ID)+9)
+		copy(key, string(user.ID)+"/")
+		binary.BigEndian.PutUint64(key[len(user.ID)+1:], seq)
+		if err := bucket.Put(key, data); nil != err {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getBoltUser returns nil when the user isn't stored
+func getBoltUser(tx *bolt.Tx, id []byte) (*model.User, error) {
+	data := tx.Bucket(usersBucket).Get(id)
+	if nil == data {
+		return nil, nil
+	}
+
+	return decodeBoltUser(data)
+}
+
+// decodeBoltUser restores the user's time zone on its statuses, JSON only
+// keeps the offset
+func decodeBoltUser(data []byte) (*model.User, error) {
+	var user model.User
+	if err := json.Unmarshal(data, &user); nil != err {
+		return nil, err
+	}
+
+	loc := user.TimeLocation()
+	for _, status := range user.Statuses {
+		if !status.From.IsZero() {
+			status.From = status.From.In(loc)
+		}
+
+		if !status.To.IsZero() {
+			status.To = status.To.In(loc)
+		}
+	}
+
+	return &user, nil
+}
+
+// indexBoltUser adds the index entries of the user
+func indexBoltUser(tx *bolt.Tx, user *model.User) error {
+	keys, long := dayKeys(user)
+	if long {
+		if err := tx.Bucket(longBucket).Put([]byte(user.ID), nil); nil != err {
+			return err
+		}
+	}
+
+	out := tx.Bucket(outBucket)
+	for _, key := range keys {
+		if err := out.Put(key, nil); nil != err {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// unindexBoltUser drops the index entries of the stored version of a user,
+// if any
+func unindexBoltUser(tx *bolt.Tx, stored *model.User) error {
+	if nil == stored {
+		return nil
+	}
+
+	if err := tx.Bucket(longBucket).Delete([]byte(stored.ID)); nil != err {
+		return err
+	}
+
+	keys, _ := dayKeys(stored)
+	out := tx.Bucket(outBucket)
+	for _, key := range keys {
+		if err := out.Delete(key); nil != err {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// reindexBolt rebuilds the indexes of every user from scratch
+func reindexBolt(tx *bolt.Tx) error {
+	for _, name := range [][]byte{outBucket, longBucket} {
+		if err := tx.DeleteBucket(name); nil != err {
+			return err
+		}
+
+		if _, err := tx.CreateBucket(name); nil != err {
+			return err
+		}
+	}
+
+	return tx.Bucket(usersBucket).ForEach(func(_, data []byte) error {
+		user, err := decodeBoltUser(data)
+		if nil != err {
+			return err
+		}
+
+		return indexBoltUser(tx, user)
+	})
+}
+
+// dayKeys lists the index keys of every UTC day touched by the user's
+// statuses, and whether some status is too long to be indexed day by day.
+// Statuses still waiting for an until don't cover any day yet
+func dayKeys(user *model.User) (keys [][]byte, long bool) {
+	seen := make(map[string]bool)
+
+	for _, status := range user.Statuses {
+		if status.To.IsZero() || status.To.Before(status.From) {
+			continue
+		}
+
+		if status.To.Sub(status.From) > maxIndexedDays*24*time.Hour {
+			long = true
+			continue
+		}
+
+		from := status.From.UTC()
+		day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+		for ; !day.After(status.To); day = day.AddDate(0, 0, 1) {
+			key := day.Format(dayKeyLayout) + "/" + string(user.ID)
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, []byte(key))
+			}
+		}
+	}
+
+	return keys, long
+}
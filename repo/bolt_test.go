@@ -0,0 +1,111 @@
+package repo
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	bolt "github.com/coreos/bbolt"
+	"github.com/italolelis/hellowork/model"
+)
+
+// countBoltKeys counts the keys of the bucket in the bbolt file at path
+func countBoltKeys(t *testing.T, path string, bucket []byte) int {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var n int
+	err = db.View(func(tx *bolt.Tx) error {
+		n = tx.Bucket(bucket).Stats().KeyN
+		return nil
+	})
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	return n
+}
+
+func TestBoltLongStatuses(t *testing.T) {
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "hellowork")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "hellowork.bolt")
+	r, err := NewBolt(path)
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	until := time.Date(2200, time.January, 1, 0, 0, 0, 0, time.UTC)
+	addUser(t, r, "retired", model.NewStatus("", day(5), until, model.OutOfOffice))
+	addUser(t, r, "week", model.NewStatus("", day(5), endOf(9), model.Vacation))
+
+	for _, tt := range []struct {
+		date time.Time
+		want int
+	}{
+		{day(4), 0},
+		{day(6), 2},
+		{time.Date(2150, time.June, 1, 0, 0, 0, 0, time.UTC), 1},
+		{until.Add(time.Hour), 0},
+	} {
+		users, err := r.FindAllOut(ctx, tt.date)
+		if nil != err {
+			t.Fatal(err)
+		}
+
+		if len(users) != tt.want {
+			t.Errorf("FindAllOut(%s) returned %v, want %d users", tt.date.Format("2006-01-02"), userIDs(users), tt.want)
+		}
+	}
+
+	r.Close()
+	if n := countBoltKeys(t, path, outBucket); n != 5 {
+		t.Errorf("the day index has %d keys, want the 5 days of the week", n)
+	}
+
+	// a file written with every day indexed gets its index rebuilt
+	db, err := bolt.Open(path, 0600, nil)
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		if err := tx.DeleteBucket(longBucket); nil != err {
+			return err
+		}
+
+		return tx.Bucket(outBucket).Put([]byte("21000101/retired"), nil)
+	})
+	db.Close()
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	if r, err = NewBolt(path); nil != err {
+		t.Fatal(err)
+	}
+
+	if err := r.Remove(ctx, "retired"); nil != err {
+		t.Fatal(err)
+	}
+
+	r.Close()
+	if n := countBoltKeys(t, path, outBucket); n != 5 {
+		t.Errorf("the rebuilt day index has %d keys, want 5", n)
+	}
+
+	if n := countBoltKeys(t, path, longBucket); n != 0 {
+		t.Errorf("%d long statuses are left after removing their user", n)
+	}
+}
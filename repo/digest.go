@@ -0,0 +1,27 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/italolelis/hellowork/model"
+)
+
+// DigestRepository stores the daily digests, at most one per channel
+type DigestRepository interface {
+	FindAllDigests(ctx context.Context) ([]*model.Digest, error)
+	// AddDigest stores the digest, replacing the one of the same channel
+	AddDigest(ctx context.Context, digest *model.Digest) error
+	// RemoveDigest returns ErrNotFound when the channel has no digest
+	RemoveDigest(ctx context.Context, channel string) error
+	// DigestSent records when the digest of the channel was posted, leaving
+	// the rest of it alone. It returns ErrNotFound when the digest was
+	// removed meanwhile
+	DigestSent(ctx context.Context, channel string, at time.Time) error
+}
+
+// Storage is everything hellowork keeps, every backend implements it
+type Storage interface {
+	Repository
+	DigestRepository
+}
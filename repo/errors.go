@@ -0,0 +1,49 @@
+package repo
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	ErrNotFound = errors.New("user not found")
+	ErrConflict = errors.New("user was changed in the meantime")
+)
+
+// Error is a failure of the storage itself. Transient ones, such as a
+// timeout, a lost connection or a locked database, may go away by retrying
+// later while permanent ones won't
+type Error struct {
+	Err       error
+	Transient bool
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+// IsTransient reports whether retrying later may succeed
+func IsTransient(err error) bool {
+	if e, ok := err.(*Error); ok {
+		return e.Transient
+	}
+
+	return err == context.DeadlineExceeded
+}
+
+func transient(err error) error {
+	return &Error{err, true}
+}
+
+func permanent(err error) error {
+	return &Error{err, false}
+}
+
+// contextError turns an expired or cancelled context into a transient error
+func contextError(ctx context.Context) error {
+	if err := ctx.Err(); nil != err {
+		return transient(err)
+	}
+
+	return nil
+}
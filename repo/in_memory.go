@@ -1,68 +1,194 @@
 package repo
 
 import (
+	"context"
+	"sync"
 	"time"
 
 	"github.com/italolelis/hellowork/model"
 )
 
+// InMemory keeps the users in a map guarded by a lock. It stores and hands
+// out copies, so callers can change the users they get without racing with
+// other commands. Their statuses are kept in an interval index to find who
+// is out without scanning everybody
 type InMemory struct {
-	users map[model.UserID]*model.User
+	mu      sync.RWMutex
+	users   map[model.UserID]*model.User
+	events  map[model.UserID][]*model.Event
+	out     *intervalIndex
+	digests map[string]model.Digest
 }
 
 func NewInMemory() *InMemory {
-	return &InMemory{make(map[model.UserID]*model.User)}
+	return &InMemory{
+		users:   make(map[model.UserID]*model.User),
+		events:  make(map[model.UserID][]*model.Event),
+		out:     newIntervalIndex(),
+		digests: make(map[string]model.Digest),
+	}
 }
 
-func (r *InMemory) Find(id string) *model.User {
+func (r *InMemory) Find(ctx context.Context, id string) (*model.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	user, exists := r.users[model.UserID(id)]
 
 	if !exists {
-		return nil
+		return nil, ErrNotFound
 	}
 
-	return user
+	return user.Copy(), nil
 }
 
-func (r *InMemory) FindAll() []*model.User {
+func (r *InMemory) FindAll(ctx context.Context) ([]*model.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	var users []*model.User
 
 	for _, user := range r.users {
-		users = append(users, user)
+		users = append(users, user.Copy())
 	}
 
-	return users
+	return users, nil
 }
 
-func (r *InMemory) FindAllOut(date time.Time) []*model.User {
+func (r *InMemory) FindAllOut(ctx context.Context, date time.Time) ([]*model.User, error) {
+	return r.FindAllOutBetween(ctx, date, date)
+}
+
+func (r *InMemory) FindAllOutBetween(ctx context.Context, from time.Time, to time.Time) ([]*model.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	var users []*model.User
 
-	for _, user := range r.users {
-		if user.IsAvailable(date) {
-			users = append(users, user)
-		}
+	for _, id := range r.out.overlapping(from, to) {
+		users = append(users, r.users[id].Copy())
 	}
 
-	return users
+	return users, nil
 }
 
-func (r *InMemory) FindAllByID(ids []string, date time.Time) []*model.User {
+func (r *InMemory) FindAllByID(ctx context.Context, ids []string, date time.Time) ([]*model.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	var users []*model.User
 
 	for _, id := range ids {
 		user, exists := r.users[model.UserID(id)]
-		if exists {
-			users = append(users, user)
+		if exists && user.IsOutDuring(date, date) {
+			users = append(users, user.Copy())
 		}
 	}
 
-	return users
+	return users, nil
 }
 
-func (r *InMemory) Add(user *model.User) {
-	r.users[user.ID] = user
+func (r *InMemory) Add(ctx context.Context, user *model.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var version int
+	stored, exists := r.users[user.ID]
+	if exists {
+		version = stored.Version
+	}
+
+	if version != user.Version {
+		return ErrConflict
+	}
+
+	if exists {
+		r.out.remove(stored)
+	}
+
+	r.events[user.ID] = append(r.events[user.ID], user.Changes()...)
+	user.ClearChanges()
+	user.Version++
+	stored = user.Copy()
+	r.users[user.ID] = stored
+	r.out.add(stored)
+
+	return nil
 }
 
-func (r *InMemory) Remove(id string) {
+func (r *InMemory) Remove(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, exists := r.users[model.UserID(id)]
+	if !exists {
+		return ErrNotFound
+	}
+
+	r.out.remove(stored)
 	delete(r.users, model.UserID(id))
+
+	return nil
+}
+
+func (r *InMemory) History(ctx context.Context, id string) ([]*model.Event, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	events, exists := r.events[model.UserID(id)]
+	if !exists {
+		return nil, ErrNotFound
+	}
+
+	return append([]*model.Event(nil), events...), nil
+}
+
+func (r *InMemory) FindAllDigests(ctx context.Context) ([]*model.Digest, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var digests []*model.Digest
+	for _, digest := range r.digests {
+		copied := digest
+		digests = append(digests, &copied)
+	}
+
+	return digests, nil
+}
+
+func (r *InMemory) AddDigest(ctx context.Context, digest *model.Digest) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.digests[digest.Channel] = *digest
+
+	return nil
+}
+
+func (r *InMemory) RemoveDigest(ctx context.Context, channel string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.digests[channel]; !exists {
+		return ErrNotFound
+	}
+
+	delete(r.digests, channel)
+
+	return nil
+}
+
+func (r *InMemory) DigestSent(ctx context.Context, channel string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	digest, exists := r.digests[channel]
+	if !exists {
+		return ErrNotFound
+	}
+
+	digest.LastSent = at
+	r.digests[channel] = digest
+
+	return nil
 }
@@ -0,0 +1,184 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/italolelis/hellowork/model"
+)
+
+func TestInMemoryFindAllOut(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	out := model.NewUser("out")
+	out.AddStatus(model.NewStatus("", now.Add(-24*time.Hour), now.Add(24*time.Hour), model.Vacation), "")
+	available := model.NewUser("available")
+	available.AddStatus(model.NewStatus("", now.Add(48*time.Hour), now.Add(72*time.Hour), model.Vacation), "")
+
+	r := NewInMemory()
+	r.Add(ctx, out)
+	r.Add(ctx, available)
+
+	users, err := r.FindAllOut(ctx, now)
+	if nil != err {
+		t.Fatal(err)
+	}
+	if len(users) != 1 || users[0].ID != "out" {
+		t.Errorf("FindAllOut returned %v, want only the user who is out", users)
+	}
+}
+
+// TestInMemoryConcurrentUse is meant to run with -race: the writers update
+// their own user and a shared one while readers change the copies they get
+func TestInMemoryConcurrentUse(t *testing.T) {
+	const (
+		writers = 4
+		updates = 20
+	)
+
+	ctx := context.Background()
+	now := time.Now()
+	r := NewInMemory()
+	r.Add(ctx, model.NewUser("shared"))
+	for w := 0; w < writers; w++ {
+		r.Add(ctx, model.NewUser(model.UserID(fmt.Sprintf("writer%d", w))))
+	}
+
+	addStatus := func(id string, i int) error {
+		return Update(ctx, r, id, func(user *model.User) error {
+			from := now.Add(time.Duration(i) * time.Hour)
+			user.AddStatus(model.NewStatus("", from, from.Add(time.Hour), model.Vacation), "")
+			return nil
+		})
+	}
+
+	var wg sync.WaitGroup
+	done := make(chan struct{})
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			for i := 0; i < updates; i++ {
+				if err := addStatus(id, i); nil != err {
+					t.Error(err)
+				}
+				for err := ErrConflict; err == ErrConflict; {
+					err = addStatus("shared", i)
+				}
+			}
+		}(fmt.Sprintf("writer%d", w))
+	}
+
+	var readers sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				users, _ := r.FindAllOutBetween(ctx, now, now.Add(updates*time.Hour))
+				for _, user := range users {
+					for _, status := range user.Statuses {
+						status.Reason = model.Sick
+					}
+					user.Statuses = nil
+				}
+				if user, err := r.Find(ctx, "shared"); nil == err {
+					user.Version = 0
+				}
+				runtime.Gosched()
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(done)
+	readers.Wait()
+
+	users, err := r.FindAll(ctx)
+	if nil != err {
+		t.Fatal(err)
+	}
+	for _, user := range users {
+		want := updates
+		if user.ID == "shared" {
+			want = writers * updates
+		}
+		if len(user.Statuses) != want {
+			t.Errorf("%s has %d statuses, want %d", user.ID, len(user.Statuses), want)
+		}
+		for _, status := range user.Statuses {
+			if status.Reason != model.Vacation {
+				t.Errorf("%s has a %s status changed through a copy", user.ID, status.Reason)
+			}
+		}
+	}
+}
+
+// newBenchmarkRepository holds 10k users, each with a week out somewhere in
+// the coming year
+func newBenchmarkRepository(b *testing.B, now time.Time) *InMemory {
+	ctx := context.Background()
+	r := NewInMemory()
+	for i := 0; i < 10000; i++ {
+		user := model.NewUser(model.UserID(fmt.Sprintf("user%d", i)))
+		from := now.Add(time.Duration(i%365) * 24 * time.Hour)
+		user.AddStatus(model.NewStatus("", from, from.Add(7*24*time.Hour), model.Vacation), "")
+		if err := r.Add(ctx, user); nil != err {
+			b.Fatal(err)
+		}
+	}
+
+	return r
+}
+
+func BenchmarkInMemoryFindAllOut(b *testing.B) {
+	ctx := context.Background()
+	now := time.Now()
+	r := newBenchmarkRepository(b, now)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := r.FindAllOut(ctx, now.Add(100*24*time.Hour)); nil != err {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkInMemoryFindAllOutBetween(b *testing.B) {
+	ctx := context.Background()
+	now := time.Now()
+	r := newBenchmarkRepository(b, now)
+	from := now.Add(100 * 24 * time.Hour)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := r.FindAllOutBetween(ctx, from, from.Add(30*24*time.Hour)); nil != err {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkInMemoryFindOverlapping(b *testing.B) {
+	ctx := context.Background()
+	now := time.Now()
+	r := newBenchmarkRepository(b, now)
+	date := now.Add(100 * 24 * time.Hour)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := FindOverlapping(ctx, r, "user100", date); nil != err {
+			b.Fatal(err)
+		}
+	}
+}
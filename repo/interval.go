@@ -0,0 +1,206 @@
+package repo
+
+import (
+	"math/rand"
+	"time"
+
+	"github.com/italolelis/hellowork/model"
+)
+
+// intervalIndex is an interval tree over the statuses of the users, so
+// finding who is out during a period costs O(log n + k) instead of a scan of
+// every status. It's a treap ordered by the start of the statuses where
+// every node also knows the latest end below it. It isn't safe for
+// concurrent use, the repository guards it
+type intervalIndex struct {
+	root *intervalNode
+}
+
+type intervalNode struct {
+	from, to    int64
+	user        model.UserID
+	priority    int64
+	maxTo       int64
+	left, right *intervalNode
+}
+
+func newIntervalIndex() *intervalIndex {
+	return &intervalIndex{}
+}
+
+// add indexes the statuses of the user. Statuses still waiting for an until
+// don't cover any time yet and are left out
+func (ix *intervalIndex) add(user *model.User) {
+	for _, status := range user.Statuses {
+		if status.To.IsZero() || status.To.Before(status.From) {
+			continue
+		}
+
+		node := &intervalNode{
+			from:     status.From.UnixNano(),
+			to:       status.To.UnixNano(),
+			user:     user.ID,
+			priority: rand.Int63(),
+		}
+		node.maxTo = node.to
+		ix.root = insertInterval(ix.root, node)
+	}
+}
+
+// remove drops the statuses of the user that add indexed
+func (ix *intervalIndex) remove(user *model.User) {
+	for _, status := range user.Statuses {
+		if status.To.IsZero() || status.To.Before(status.From) {
+			continue
+		}
+
+		ix.root = removeInterval(ix.root, status.From.UnixNano(), status.To.UnixNano(), user.ID)
+	}
+}
+
+// overlapping returns every user with a status overlapping [from, to], each
+// one once
+func (ix *intervalIndex) overlapping(from time.Time, to time.Time) []model.UserID {
+	var ids []model.UserID
+	seen := make(map[model.UserID]bool)
+
+	ix.root.each(from.UnixNano(), to.UnixNano(), func(id model.UserID) {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	})
+
+	return ids
+}
+
+// each visits the nodes overlapping [from, to], skipping the subtrees that
+// end before from and the ones that start after to
+func (n *intervalNode) each(from int64, to int64, visit func(model.UserID)) {
+	if nil == n || n.maxTo < from {
+		return
+	}
+
+	n.left.each(from, to, visit)
+	if n.from > to {
+		return
+	}
+
+	if n.to >= from {
+		visit(n.user)
+	}
+
+	n.right.each(from, to, visit)
+}
+
+// compare orders the nodes by start, end and user
+func (n *intervalNode) compare(from int64, to int64, user model.UserID) int {
+	switch {
+	case from != n.from:
+		return sign(from - n.from)
+	case to != n.to:
+		return sign(to - n.to)
+	case user < n.user:
+		return -1
+	case user > n.user:
+		return 1
+	}
+
+	return 0
+}
+
+func (n *intervalNode) update() {
+	n.maxTo = n.to
+	if nil != n.left && n.left.maxTo > n.maxTo {
+		n.maxTo = n.left.maxTo
+	}
+
+	if nil != n.right && n.right.maxTo > n.maxTo {
+		n.maxTo = n.right.maxTo
+	}
+}
+
+func insertInterval(n *intervalNode, node *intervalNode) *intervalNode {
+	if nil == n {
+		return node
+	}
+
+	if n.compare(node.from, node.to, node.user) < 0 {
+		n.left = insertInterval(n.left, node)
+		if n.left.priority > n.priority {
+			n = rotateRight(n)
+		}
+	} else {
+		n.right = insertInterval(n.right, node)
+		if n.right.priority > n.priority {
+			n = rotateLeft(n)
+		}
+	}
+
+	n.update()
+	return n
+}
+
+func removeInterval(n *intervalNode, from int64, to int64, user model.UserID) *intervalNode {
+	if nil == n {
+		return nil
+	}
+
+	switch n.compare(from, to, user) {
+	case -1:
+		n.left = removeInterval(n.left, from, to, user)
+	case 1:
+		n.right = removeInterval(n.right, from, to, user)
+	default:
+		return mergeIntervals(n.left, n.right)
+	}
+
+	n.update()
+	return n
+}
+
+// mergeIntervals joins two treaps where everything in a comes before b
+func mergeIntervals(a *intervalNode, b *intervalNode) *intervalNode {
+	switch {
+	case nil == a:
+		return b
+	case nil == b:
+		return a
+	case a.priority > b.priority:
+		a.right = mergeIntervals(a.right, b)
+		a.update()
+		return a
+	}
+
+	b.left = mergeIntervals(a, b.left)
+	b.update()
+	return b
+}
+
+func rotateRight(n *intervalNode) *intervalNode {
+	left := n.left
+	n.left = left.right
+	n.update()
+	left.right = n
+	left.update()
+
+	return left
+}
+
+func rotateLeft(n *intervalNode) *intervalNode {
+	right := n.right
+	n.right = right.left
+	n.update()
+	right.left = n
+	right.update()
+
+	return right
+}
+
+func sign(n int64) int {
+	if n < 0 {
+		return -1
+	}
+
+	return 1
+}
@@ -0,0 +1,529 @@
+package repo
+
+import (
+	"context"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/italolelis/hellowork/model"
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const (
+	usersCollection   = "users"
+	eventsCollection  = "events"
+	digestsCollection = "digests"
+)
+
+// mongoUser is how a model.User is stored, one document per user with its
+// statuses. Its history is kept in the events collection so it outlives the
+// user, Events is only read to move the history embedded by older versions
+type mongoUser struct {
+	ID       string        `bson:"_id"`
+	Username string        `bson:"username"`
+	Location string        `bson:"location"`
+	Statuses []mongoStatus `bson:"statuses"`
+	Version  int           `bson:"version"`
+	Profile  *mongoProfile `bson:"profile,omitempty"`
+	Events   []mongoEvent  `bson:"events,omitempty"`
+}
+
+type mongoProfile struct {
+	Token  string    `bson:"token"`
+	Status string    `bson:"status"`
+	Until  time.Time `bson:"until"`
+	Text   string    `bson:"text"`
+	Emoji  string    `bson:"emoji"`
+}
+
+type mongoStatus struct {
+	ID          string    `bson:"id"`
+	Description string    `bson:"description"`
+	Reason      string    `bson:"reason"`
+	From        time.Time `bson:"from"`
+	To          time.Time `bson:"to"`
+	Channels    []string  `bson:"channels,omitempty"`
+}
+
+type mongoDigest struct {
+	Channel  string    `bson:"_id"`
+	Hour     int       `bson:"hour"`
+	Minute   int       `bson:"minute"`
+	Location string    `bson:"location"`
+	Language string    `bson:"language"`
+	LastSent time.Time `bson:"last_sent"`
+}
+
+// mongoEvent is an event of the history of User, which sorts by its _id in
+// the order the events were added
+type mongoEvent struct {
+	ID     bson.ObjectId `bson:"_id,omitempty"`
+	User   string        `bson:"user,omitempty"`
+	Type   string        `bson:"type"`
+	Author string        `bson:"author"`
+	At     time.Time     `bson:"at"`
+	Status mongoStatus   `bson:"status"`
+}
+
+// Mongo is a Repository backed by MongoDB. Writes go through the write
+// session and so does Find, whose user is usually saved back. The other
+// queries go through the read one, which may point to a replica of the same
+// database
+type Mongo struct {
+	write *mgo.Session
+	read  *mgo.Session
+}
+
+// NewMongo connects to the write and read DSNs, such as
+// "mongodb://mongodb/hellowork", and makes sure the indexes exist. The read
+// DSN falls back to the write one when it's empty
+func NewMongo(writeDSN string, readDSN string) (*Mongo, error) {
+	write, err := mgo.Dial(writeDSN)
+	if nil != err {
+		return nil, err
+	}
+
+	if len(readDSN) == 0 {
+		readDSN = writeDSN
+	}
+
+	read, err := mgo.Dial(readDSN)
+	if nil != err {
+		write.Close()
+		return nil, err
+	}
+
+	read.SetMode(mgo.SecondaryPreferred, true)
+
+	r := &Mongo{write, read}
+	if err := r.ensureIndexes(); nil != err {
+		r.Close()
+		return nil, err
+	}
+
+	if err := r.moveEmbeddedEvents(); nil != err {
+		r.Close()
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// ensureIndexes indexes the status periods for FindAllOut and the events by
+// user for History, users are already indexed by their _id
+func (r *Mongo) ensureIndexes() error {
+	session := r.write.Copy()
+	defer session.Close()
+
+	err := session.DB("").C(usersCollection).EnsureIndex(mgo.Index{
+		Key:        []string{"statuses.from", "statuses.to"},
+		Background: true,
+	})
+	if nil != err {
+		return err
+	}
+
+	return session.DB("").C(eventsCollection).EnsureIndex(mgo.Index{
+		Key:        []string{"user", "_id"},
+		Background: true,
+	})
+}
+
+// moveEmbeddedEvents moves the history embedded in the users by older
+// versions to the events collection
+func (r *Mongo) moveEmbeddedEvents() error {
+	session := r.write.Copy()
+	defer session.Close()
+
+	users := session.DB("").C(usersCollection)
+	iter := users.Find(bson.M{"events": bson.M{"$exists": true}}).Select(bson.M{"events": 1}).Iter()
+
+	var doc mongoUser
+	for iter.Next(&doc) {
+		if err := insertMongoEvents(session, doc.ID, doc.Events); nil != err {
+			iter.Close()
+			return err
+		}
+
+		if err := users.UpdateId(doc.ID, bson.M{"$unset": bson.M{"events": ""}}); nil != err {
+			iter.Close()
+			return err
+		}
+
+		doc = mongoUser{}
+	}
+
+	return iter.Close()
+}
+
+// Close closes both sessions
+func (r *Mongo) Close() error {
+	r.read.Close()
+	r.write.Close()
+
+	return nil
+}
+
+// Find reads from the write session, a replica lagging behind would hand out
+// a stale version which can only be saved back after a conflict
+func (r *Mongo) Find(ctx context.Context, id string) (*model.User, error) {
+	users, err := r.findUsers(ctx, r.write, bson.M{"_id": id})
+	if nil != err {
+		return nil, err
+	}
+
+	if len(users) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return users[0], nil
+}
+
+func (r *Mongo) FindAll(ctx context.Context) ([]*model.User, error) {
+	return r.findUsers(ctx, r.read, nil)
+}
+
+func (r *Mongo) FindAllOut(ctx context.Context, date time.Time) ([]*model.User, error) {
+	return r.FindAllOutBetween(ctx, date, date)
+}
+
+func (r *Mongo) FindAllOutBetween(ctx context.Context, from time.Time, to time.Time) ([]*model.User, error) {
+	return r.findUsers(ctx, r.read, bson.M{"statuses": overlapping(from, to)})
+}
+
+func (r *Mongo) FindAllByID(ctx context.Context, ids []string, date time.Time) ([]*model.User, error) {
+	return r.findUsers(ctx, r.read, bson.M{"_id": bson.M{"$in": ids}, "statuses": overlapping(date, date)})
+}
+
+// overlapping matches the users with a status overlapping from and to
+func overlapping(from time.Time, to time.Time) bson.M {
+	return bson.M{"$elemMatch": bson.M{
+		"from": bson.M{"$lte": to},
+		"to":   bson.M{"$gte": from},
+	}}
+}
+
+// Add stores the user along with all of its statuses, replacing the ones
+// stored before. The version in the selector makes the upsert insert a
+// duplicate _id when somebody else saved the user in the meantime.
+//
+// MongoDB can't write both collections at once, so the changes are added to
+// the history first and taken back when the user can't be saved. The history
+// may then have a change too many, but never misses a saved one
+func (r *Mongo) Add(ctx context.Context, user *model.User) error {
+	session, err := sessionFor(ctx, r.write)
+	if nil != err {
+		return err
+	}
+	defer session.Close()
+
+	events := make([]mongoEvent, 0, len(user.Changes()))
+	for _, event := range user.Changes() {
+		events = append(events, mongoEvent{
+			ID:     bson.NewObjectId(),
+			Type:   string(event.Type),
+			Author: string(event.Author),
+			At:     event.At,
+			Status: newMongoStatus(&event.Status),
+		})
+	}
+
+	if err := insertMongoEvents(session, string(user.ID), events); nil != err {
+		return mongoError(err)
+	}
+
+	selector := bson.M{"_id": string(user.ID), "version": user.Version}
+	if user.Version == 0 {
+		// also matches users stored before they had a version
+		selector["version"] = nil
+	}
+
+	doc := newMongoUser(user)
+	change := bson.M{
+		"$set": bson.M{
+			"username": doc.Username,
+			"location": doc.Location,
+			"statuses": doc.Statuses,
+			"version":  doc.Version + 1,
+		},
+	}
+
+	if nil != doc.Profile {
+		change["$set"].(bson.M)["profile"] = doc.Profile
+	} else {
+		change["$unset"] = bson.M{"profile": ""}
+	}
+
+	if _, err := session.DB("").C(usersCollection).Upsert(selector, change); nil != err {
+		ids := make([]bson.ObjectId, 0, len(events))
+		for _, event := range events {
+			ids = append(ids, event.ID)
+		}
+
+		if len(ids) > 0 {
+			session.DB("").C(eventsCollection).RemoveAll(bson.M{"_id": bson.M{"$in": ids}})
+		}
+
+		return mongoError(err)
+	}
+
+	user.ClearChanges()
+	user.Version++
+
+	return nil
+}
+
+func (r *Mongo) Remove(ctx context.Context, id string) error {
+	session, err := sessionFor(ctx, r.write)
+	if nil != err {
+		return err
+	}
+	defer session.Close()
+
+	return mongoError(session.DB("").C(usersCollection).RemoveId(id))
+}
+
+func (r *Mongo) findUsers(ctx context.Context, from *mgo.Session, query interface{}) ([]*model.User, error) {
+	session, err := sessionFor(ctx, from)
+	if nil != err {
+		return nil, err
+	}
+	defer session.Close()
+
+	var docs []mongoUser
+	err = session.DB("").C(usersCollection).Find(query).Sort("_id").All(&docs)
+	if nil != err {
+		return nil, mongoError(err)
+	}
+
+	var users []*model.User
+	for _, doc := range docs {
+		users = append(users, doc.user())
+	}
+
+	return users, nil
+}
+
+// History is read from the write session, a replica lagging behind could
+// miss the latest events
+func (r *Mongo) History(ctx context.Context, id string) ([]*model.Event, error) {
+	session, err := sessionFor(ctx, r.write)
+	if nil != err {
+		return nil, err
+	}
+	defer session.Close()
+
+	loc := time.Local
+	var doc mongoUser
+	err = session.DB("").C(usersCollection).FindId(id).One(&doc)
+	switch {
+	case nil == err:
+		loc = doc.user().TimeLocation()
+	case err != mgo.ErrNotFound:
+		return nil, mongoError(err)
+	}
+
+	var docs []mongoEvent
+	if err := session.DB("").C(eventsCollection).Find(bson.M{"user": id}).Sort("_id").All(&docs); nil != err {
+		return nil, mongoError(err)
+	}
+
+	if len(docs) == 0 && len(doc.ID) == 0 {
+		return nil, ErrNotFound
+	}
+
+	events := make([]*model.Event, 0, len(docs))
+	for _, event := range docs {
+		events = append(events, &model.Event{
+			Type:   model.EventType(event.Type),
+			User:   model.UserID(id),
+			Author: model.UserID(event.Author),
+			At:     event.At.In(loc),
+			Status: *event.Status.status(loc),
+		})
+	}
+
+	return events, nil
+}
+
+func (r *Mongo) FindAllDigests(ctx context.Context) ([]*model.Digest, error) {
+	session, err := sessionFor(ctx, r.read)
+	if nil != err {
+		return nil, err
+	}
+	defer session.Close()
+
+	var docs []mongoDigest
+	if err := session.DB("").C(digestsCollection).Find(nil).Sort("_id").All(&docs); nil != err {
+		return nil, mongoError(err)
+	}
+
+	digests := make([]*model.Digest, 0, len(docs))
+	for _, doc := range docs {
+		digest := model.Digest(doc)
+		digest.LastSent = digest.LastSent.In(digest.TimeLocation())
+		digests = append(digests, &digest)
+	}
+
+	return digests, nil
+}
+
+func (r *Mongo) AddDigest(ctx context.Context, digest *model.Digest) error {
+	session, err := sessionFor(ctx, r.write)
+	if nil != err {
+		return err
+	}
+	defer session.Close()
+
+	_, err = session.DB("").C(digestsCollection).UpsertId(digest.Channel, mongoDigest(*digest))
+	return mongoError(err)
+}
+
+func (r *Mongo) RemoveDigest(ctx context.Context, channel string) error {
+	session, err := sessionFor(ctx, r.write)
+	if nil != err {
+		return err
+	}
+	defer session.Close()
+
+	return mongoError(session.DB("").C(digestsCollection).RemoveId(channel))
+}
+
+func (r *Mongo) DigestSent(ctx context.Context, channel string, at time.Time) error {
+	session, err := sessionFor(ctx, r.write)
+	if nil != err {
+		return err
+	}
+	defer session.Close()
+
+	return mongoError(session.DB("").C(digestsCollection).UpdateId(channel, bson.M{"$set": bson.M{"last_sent": at}}))
+}
+
+// sessionFor copies the session, bounding its socket timeout by the
+// deadline of the context since mgo doesn't take one
+func sessionFor(ctx context.Context, session *mgo.Session) (*mgo.Session, error) {
+	if err := contextError(ctx); nil != err {
+		return nil, err
+	}
+
+	copied := session.Copy()
+	if deadline, ok := ctx.Deadline(); ok {
+		copied.SetSocketTimeout(time.Until(deadline))
+	}
+
+	return copied, nil
+}
+
+// mongoError maps mgo errors to the repository ones. Network failures, such
+// as a timeout or no reachable server, are transient
+func mongoError(err error) error {
+	switch {
+	case nil == err:
+		return nil
+	case err == mgo.ErrNotFound:
+		return ErrNotFound
+	case mgo.IsDup(err):
+		return ErrConflict
+	case err == io.EOF:
+		return transient(err)
+	}
+
+	if _, ok := err.(net.Error); ok {
+		return transient(err)
+	}
+
+	if strings.Contains(err.Error(), "no reachable servers") {
+		return transient(err)
+	}
+
+	return permanent(err)
+}
+
+// insertMongoEvents adds the events to the history of the user, keeping
+// their _id when they have one
+func insertMongoEvents(session *mgo.Session, id string, events []mongoEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	docs := make([]interface{}, 0, len(events))
+	for _, event := range events {
+		if len(event.ID) == 0 {
+			event.ID = bson.NewObjectId()
+		}
+
+		event.User = id
+		docs = append(docs, event)
+	}
+
+	return session.DB("").C(eventsCollection).Insert(docs...)
+}
+
+func newMongoUser(user *model.User) *mongoUser {
+	doc := &mongoUser{
+		ID:       string(user.ID),
+		Username: user.Username,
+		Location: user.Location,
+		Version:  user.Version,
+		Statuses: make([]mongoStatus, 0, len(user.Statuses)),
+	}
+
+	for _, status := range user.Statuses {
+		doc.Statuses = append(doc.Statuses, newMongoStatus(status))
+	}
+
+	if profile := user.Profile; nil != profile {
+		doc.Profile = &mongoProfile{profile.Token, string(profile.Status), profile.Until, profile.Text, profile.Emoji}
+	}
+
+	return doc
+}
+
+func newMongoStatus(status *model.Status) mongoStatus {
+	return mongoStatus{string(status.ID), status.Description, string(status.Reason), status.From, status.To, status.Channels}
+}
+
+// status restores the time zone of the status, MongoDB keeps UTC only
+func (doc *mongoStatus) status(loc *time.Location) *model.Status {
+	from, to := doc.From, doc.To
+	if !from.IsZero() {
+		from = from.In(loc)
+	}
+
+	if !to.IsZero() {
+		to = to.In(loc)
+	}
+
+	status := model.NewStatus(doc.Description, from, to, model.Reason(doc.Reason))
+	status.ID = model.StatusID(doc.ID)
+	status.Channels = doc.Channels
+
+	return status
+}
+
+func (doc *mongoUser) user() *model.User {
+	user := model.NewUser(model.UserID(doc.ID))
+	user.Username = doc.Username
+	user.Location = doc.Location
+	user.Version = doc.Version
+
+	loc := user.TimeLocation()
+	for _, status := range doc.Statuses {
+		user.Statuses = append(user.Statuses, status.status(loc))
+	}
+
+	if profile := doc.Profile; nil != profile {
+		user.Profile = &model.ProfileSync{
+			Token:  profile.Token,
+			Status: model.StatusID(profile.Status),
+			Until:  profile.Until.In(loc),
+			Text:   profile.Text,
+			Emoji:  profile.Emoji,
+		}
+	}
+
+	return user
+}
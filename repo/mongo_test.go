@@ -0,0 +1,164 @@
+package repo
+
+import (
+	"context"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/italolelis/hellowork/model"
+	mgo "gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+var (
+	mongoDialed sync.Once
+	// mongoDialErr is why the first test couldn't reach mongod, the others
+	// skip without waiting for it again
+	mongoDialErr error
+)
+
+// mongoTestDSN returns MONGO_TEST_DSN, or the DSN of a local mongod, after
+// wiping its database. The test is skipped when no server answers
+func mongoTestDSN(t *testing.T) string {
+	dsn := os.Getenv("MONGO_TEST_DSN")
+	if len(dsn) == 0 {
+		dsn = "mongodb://localhost/hellowork_test"
+	}
+
+	var session *mgo.Session
+	mongoDialed.Do(func() {
+		session, mongoDialErr = mgo.DialWithTimeout(dsn, time.Second)
+	})
+	if nil != mongoDialErr {
+		t.Skipf("no mongod at %s: %v", dsn, mongoDialErr)
+	}
+
+	if nil == session {
+		var err error
+		if session, err = mgo.DialWithTimeout(dsn, time.Second); nil != err {
+			t.Fatal(err)
+		}
+	}
+	defer session.Close()
+
+	if err := session.DB("").DropDatabase(); nil != err {
+		t.Fatal(err)
+	}
+
+	return dsn
+}
+
+// newTestMongo connects to an empty database, which is dropped again by
+// the returned func
+func newTestMongo(t *testing.T) (*Mongo, func()) {
+	r, err := NewMongo(mongoTestDSN(t), "")
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	return r, func() {
+		r.write.DB("").DropDatabase()
+		r.Close()
+	}
+}
+
+func TestMongoStorage(t *testing.T) {
+	testStorage(t, func(t *testing.T) (Storage, func()) {
+		return newTestMongo(t)
+	})
+}
+
+func TestMongoReadsUsersToChangeFromTheWriteSession(t *testing.T) {
+	// an empty database stands for a replica lagging behind
+	info, err := mgo.ParseURL(mongoTestDSN(t))
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	r, done := newTestMongo(t)
+	defer done()
+
+	info.Database += "_replica"
+	info.Timeout = time.Second
+	replica, err := mgo.DialWithInfo(info)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer replica.DB("").DropDatabase()
+
+	r.read.Close()
+	r.read = replica
+
+	ctx := context.Background()
+	status := model.NewStatus("", day(5), endOf(9), model.Vacation)
+	addUser(t, r, "U1", status)
+
+	moved := *status
+	moved.To = endOf(12)
+	if err := UpdateStatus(ctx, r, "U1", &moved, "U1"); nil != err {
+		t.Fatalf("UpdateStatus: %v", err)
+	}
+
+	user, err := r.Find(ctx, "U1")
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	if user.Version != 2 || !user.Statuses[0].To.Equal(endOf(12)) {
+		t.Errorf("Find returned version %d until %s", user.Version, user.Statuses[0].To)
+	}
+
+	if users, err := r.FindAll(ctx); nil != err || len(users) != 0 {
+		t.Errorf("FindAll returned %v and %v, want nothing from the empty replica", userIDs(users), err)
+	}
+}
+
+func TestMongoMovesEmbeddedEvents(t *testing.T) {
+	dsn := mongoTestDSN(t)
+	session, err := mgo.Dial(dsn)
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer session.Close()
+	defer session.DB("").DropDatabase()
+
+	status := newMongoStatus(model.NewStatus("", day(5), endOf(9), model.Vacation))
+	legacy := bson.M{
+		"_id":      "U1",
+		"statuses": []mongoStatus{status},
+		"version":  1,
+		"events": []bson.M{
+			{"type": string(model.StatusCreated), "author": "U1", "at": day(1), "status": status},
+			{"type": string(model.StatusAnnounced), "author": "U2", "at": day(2), "status": status},
+		},
+	}
+	if err := session.DB("").C(usersCollection).Insert(legacy); nil != err {
+		t.Fatal(err)
+	}
+
+	r, err := NewMongo(dsn, "")
+	if nil != err {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	events, err := r.History(context.Background(), "U1")
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	if len(events) != 2 || events[0].Type != model.StatusCreated || events[1].Type != model.StatusAnnounced {
+		t.Errorf("History returned %v, want the embedded events in order", events)
+	}
+
+	n, err := session.DB("").C(usersCollection).Find(bson.M{"events": bson.M{"$exists": true}}).Count()
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	if n != 0 {
+		t.Errorf("%d users still embed their events", n)
+	}
+}
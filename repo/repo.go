@@ -1,15 +1,76 @@
 package repo
 
 import (
+	"context"
 	"time"
+
 	"github.com/italolelis/hellowork/model"
 )
 
+// Repository stores the users along with their statuses. Find returns
+// ErrNotFound for unknown users and Add returns ErrConflict when the user was
+// saved by someone else since it was loaded. Any other failure is an *Error.
+//
+// Add appends the changes recorded on the user to its history in the same
+// write as the statuses, which are kept as a snapshot of that history
 type Repository interface {
-	Find(id string) *model.User
-	FindAll() []*model.User
-	FindAllOut(date time.Time) []*model.User
-	FindAllByID(usernames []string, date time.Time) []*model.User
-	Add(user *model.User)
-	Remove(username string)
+	Find(ctx context.Context, id string) (*model.User, error)
+	FindAll(ctx context.Context) ([]*model.User, error)
+	// FindAllOut returns the users with a status covering the date
+	FindAllOut(ctx context.Context, date time.Time) ([]*model.User, error)
+	// FindAllOutBetween returns the users with a status overlapping the
+	// period from and to, both included
+	FindAllOutBetween(ctx context.Context, from time.Time, to time.Time) ([]*model.User, error)
+	// FindAllByID returns which of the given users are out on the date
+	FindAllByID(ctx context.Context, ids []string, date time.Time) ([]*model.User, error)
+	Add(ctx context.Context, user *model.User) error
+	Remove(ctx context.Context, id string) error
+	// History returns every event of the user, oldest first. It's kept even
+	// after the user is removed
+	History(ctx context.Context, id string) ([]*model.Event, error)
+}
+
+// Rebuild replays the history of the user over its statuses, to repair the
+// snapshots saved by a buggy version
+func Rebuild(ctx context.Context, r Repository, id string) error {
+	user, err := r.Find(ctx, id)
+	if nil != err {
+		return err
+	}
+
+	events, err := r.History(ctx, id)
+	if nil != err {
+		return err
+	}
+
+	user.Replay(events)
+	return r.Add(ctx, user)
+}
+
+// FindOverlapping returns the other users out at any time during the status
+// the given user is on at the date or, when there is none, the next one
+func FindOverlapping(ctx context.Context, r Repository, id string, date time.Time) ([]*model.User, error) {
+	user, err := r.Find(ctx, id)
+	if nil != err {
+		return nil, err
+	}
+
+	status := user.StatusFrom(date)
+	if nil == status {
+		return nil, nil
+	}
+
+	users, err := r.FindAllOutBetween(ctx, status.From, status.To)
+	if nil != err {
+		return nil, err
+	}
+
+	var others []*model.User
+	for _, other := range users {
+		if other.ID != user.ID {
+			others = append(others, other)
+		}
+	}
+
+	return others, nil
 }
@@ -0,0 +1,436 @@
+package repo
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/italolelis/hellowork/model"
+)
+
+func TestInMemoryStorage(t *testing.T) {
+	testStorage(t, func(t *testing.T) (Storage, func()) {
+		return NewInMemory(), func() {}
+	})
+}
+
+func TestSQLiteStorage(t *testing.T) {
+	testStorage(t, func(t *testing.T) (Storage, func()) {
+		return newTestSQLite(t)
+	})
+}
+
+func TestBoltStorage(t *testing.T) {
+	testStorage(t, func(t *testing.T) (Storage, func()) {
+		dir, err := ioutil.TempDir("", "hellowork")
+		if nil != err {
+			t.Fatal(err)
+		}
+
+		r, err := NewBolt(filepath.Join(dir, "hellowork.bolt"))
+		if nil != err {
+			os.RemoveAll(dir)
+			t.Fatal(err)
+		}
+
+		return r, func() {
+			r.Close()
+			os.RemoveAll(dir)
+		}
+	})
+}
+
+// testStorage runs the behaviour every backend must share against fresh
+// storages made by open, which also returns how to dispose of them
+func testStorage(t *testing.T, open func(t *testing.T) (Storage, func())) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, r Storage)
+	}{
+		{"unknown user", testUnknownUser},
+		{"round trip", testRoundTrip},
+		{"conflict", testConflict},
+		{"who is out", testWhoIsOut},
+		{"overlapping", testOverlapping},
+		{"history", testHistory},
+		{"rebuild", testRebuild},
+		{"digests", testDigests},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, done := open(t)
+			defer done()
+
+			tt.test(t, r)
+		})
+	}
+}
+
+// day is midnight of the day of March 2018 in UTC
+func day(d int) time.Time {
+	return time.Date(2018, time.March, d, 0, 0, 0, 0, time.UTC)
+}
+
+// endOf is the last instant of the day of March 2018 in UTC
+func endOf(d int) time.Time {
+	return day(d + 1).Add(-time.Nanosecond)
+}
+
+func addUser(t *testing.T, r Storage, id model.UserID, statuses ...*model.Status) *model.User {
+	user := model.NewUser(id)
+	for _, status := range statuses {
+		user.AddStatus(status, id)
+	}
+
+	if err := r.Add(context.Background(), user); nil != err {
+		t.Fatalf("Add(%s): %v", id, err)
+	}
+
+	return user
+}
+
+func userIDs(users []*model.User) []string {
+	ids := []string{}
+	for _, user := range users {
+		ids = append(ids, string(user.ID))
+	}
+
+	sort.Strings(ids)
+	return ids
+}
+
+func testUnknownUser(t *testing.T, r Storage) {
+	ctx := context.Background()
+	if _, err := r.Find(ctx, "nobody"); err != ErrNotFound {
+		t.Errorf("Find: got %v, want ErrNotFound", err)
+	}
+
+	if err := r.Remove(ctx, "nobody"); err != ErrNotFound {
+		t.Errorf("Remove: got %v, want ErrNotFound", err)
+	}
+
+	if _, err := r.History(ctx, "nobody"); err != ErrNotFound {
+		t.Errorf("History: got %v, want ErrNotFound", err)
+	}
+}
+
+func testRoundTrip(t *testing.T, r Storage) {
+	ctx := context.Background()
+
+	vacation := model.NewStatus("skiing", day(5), endOf(9), model.Vacation)
+	vacation.Channels = []string{"C1", "C2"}
+	morning := model.NewStatus("", day(12), day(12).Add(12*time.Hour-time.Nanosecond), model.Sick)
+
+	user := model.NewUser("U1")
+	user.Username = "jane"
+	user.Location = "Europe/Berlin"
+	user.AddStatus(vacation, "U1")
+	user.AddStatus(morning, "U2")
+	user.Profile = &model.ProfileSync{Token: "xoxp", Status: vacation.ID, Until: endOf(9), Text: "in the office", Emoji: ":office:"}
+	if err := r.Add(ctx, user); nil != err {
+		t.Fatal(err)
+	}
+
+	if user.Version != 1 || len(user.Changes()) != 0 {
+		t.Errorf("Add left version %d and %d changes, want 1 and none", user.Version, len(user.Changes()))
+	}
+
+	found, err := r.Find(ctx, "U1")
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	if found.Username != "jane" || found.Location != "Europe/Berlin" || found.Version != 1 {
+		t.Errorf("Find returned %q in %q at version %d", found.Username, found.Location, found.Version)
+	}
+
+	if len(found.Statuses) != 2 {
+		t.Fatalf("Find returned %d statuses, want 2", len(found.Statuses))
+	}
+
+	for i, want := range []*model.Status{vacation, morning} {
+		got := found.Statuses[i]
+		if got.ID != want.ID || got.Description != want.Description || got.Reason != want.Reason ||
+			!got.From.Equal(want.From) || !got.To.Equal(want.To) || len(got.Channels) != len(want.Channels) {
+			t.Errorf("status %d is %+v, want %+v", i, got, want)
+		}
+	}
+
+	if !reflect.DeepEqual(found.Statuses[0].Channels, vacation.Channels) {
+		t.Errorf("channels are %v, want %v", found.Statuses[0].Channels, vacation.Channels)
+	}
+
+	profile := found.Profile
+	if nil == profile || profile.Token != "xoxp" || profile.Status != vacation.ID || !profile.Until.Equal(endOf(9)) ||
+		profile.Text != "in the office" || profile.Emoji != ":office:" {
+		t.Errorf("profile is %+v", profile)
+	}
+
+	all, err := r.FindAll(ctx)
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	if ids := userIDs(all); !reflect.DeepEqual(ids, []string{"U1"}) {
+		t.Errorf("FindAll returned %v", ids)
+	}
+}
+
+func testConflict(t *testing.T, r Storage) {
+	ctx := context.Background()
+	addUser(t, r, "U1")
+
+	first, err := r.Find(ctx, "U1")
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	second, err := r.Find(ctx, "U1")
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	first.AddStatus(model.NewStatus("", day(5), endOf(5), model.Remote), "U1")
+	if err := r.Add(ctx, first); nil != err {
+		t.Fatal(err)
+	}
+
+	second.AddStatus(model.NewStatus("", day(6), endOf(6), model.Sick), "U1")
+	if err := r.Add(ctx, second); err != ErrConflict {
+		t.Errorf("Add of a stale user: got %v, want ErrConflict", err)
+	}
+
+	found, err := r.Find(ctx, "U1")
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	if len(found.Statuses) != 1 || found.Statuses[0].Reason != model.Remote {
+		t.Errorf("the stale Add changed the statuses to %v", found.Statuses)
+	}
+}
+
+func testWhoIsOut(t *testing.T, r Storage) {
+	ctx := context.Background()
+	addUser(t, r, "out", model.NewStatus("", day(5), endOf(9), model.Vacation))
+	addUser(t, r, "later", model.NewStatus("", day(19), endOf(23), model.WorkTrip))
+	addUser(t, r, "morning", model.NewStatus("", day(7), day(7).Add(12*time.Hour-time.Nanosecond), model.Sick))
+	addUser(t, r, "in")
+
+	tests := []struct {
+		name     string
+		from, to time.Time
+		want     []string
+	}{
+		{"inside a status", day(6), day(6), []string{"out"}},
+		{"first instant", day(5), day(5), []string{"out"}},
+		{"last instant", endOf(9), endOf(9), []string{"out"}},
+		{"after every status", day(10), day(10), []string{}},
+		{"half day", day(7).Add(9 * time.Hour), day(7).Add(9 * time.Hour), []string{"morning", "out"}},
+		{"afternoon of the half day", day(7).Add(14 * time.Hour), day(7).Add(14 * time.Hour), []string{"out"}},
+		{"period overlapping the end", endOf(9), day(12), []string{"out"}},
+		{"period between statuses", day(10), endOf(18), []string{}},
+		{"period covering everything", day(1), day(31), []string{"later", "morning", "out"}},
+	}
+
+	for _, tt := range tests {
+		users, err := r.FindAllOutBetween(ctx, tt.from, tt.to)
+		if nil != err {
+			t.Fatal(err)
+		}
+
+		if ids := userIDs(users); !reflect.DeepEqual(ids, tt.want) {
+			t.Errorf("FindAllOutBetween %s: got %v, want %v", tt.name, ids, tt.want)
+		}
+
+		if !tt.from.Equal(tt.to) {
+			continue
+		}
+
+		users, err = r.FindAllOut(ctx, tt.from)
+		if nil != err {
+			t.Fatal(err)
+		}
+
+		if ids := userIDs(users); !reflect.DeepEqual(ids, tt.want) {
+			t.Errorf("FindAllOut %s: got %v, want %v", tt.name, ids, tt.want)
+		}
+	}
+
+	users, err := r.FindAllByID(ctx, []string{"out", "later", "in", "nobody"}, day(6))
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	if ids := userIDs(users); !reflect.DeepEqual(ids, []string{"out"}) {
+		t.Errorf("FindAllByID returned %v", ids)
+	}
+}
+
+func testOverlapping(t *testing.T, r Storage) {
+	ctx := context.Background()
+	addUser(t, r, "out", model.NewStatus("", day(5), endOf(9), model.Vacation), model.NewStatus("", day(19), endOf(19), model.Remote))
+	addUser(t, r, "overlap", model.NewStatus("", day(9), endOf(12), model.WorkTrip))
+	addUser(t, r, "morning", model.NewStatus("", day(7), day(7).Add(12*time.Hour-time.Nanosecond), model.Sick))
+	addUser(t, r, "later", model.NewStatus("", day(19), endOf(23), model.WorkTrip))
+	addUser(t, r, "in")
+
+	tests := []struct {
+		name string
+		id   string
+		date time.Time
+		want []string
+	}{
+		{"during the status", "out", day(6), []string{"morning", "overlap"}},
+		{"before the status", "out", day(1), []string{"morning", "overlap"}},
+		{"between statuses", "out", day(15), []string{"later"}},
+		{"after every status", "out", day(25), []string{}},
+		{"nothing planned", "in", day(6), []string{}},
+		{"at the end of another", "overlap", day(12), []string{"out"}},
+	}
+
+	for _, tt := range tests {
+		users, err := FindOverlapping(ctx, r, tt.id, tt.date)
+		if nil != err {
+			t.Fatal(err)
+		}
+
+		if ids := userIDs(users); !reflect.DeepEqual(ids, tt.want) {
+			t.Errorf("FindOverlapping %s: got %v, want %v", tt.name, ids, tt.want)
+		}
+	}
+
+	if _, err := FindOverlapping(ctx, r, "nobody", day(6)); err != ErrNotFound {
+		t.Errorf("FindOverlapping of an unknown user: got %v, want ErrNotFound", err)
+	}
+}
+
+func testHistory(t *testing.T, r Storage) {
+	ctx := context.Background()
+	status := model.NewStatus("", day(5), endOf(9), model.Vacation)
+	addUser(t, r, "U1", status)
+
+	err := Update(ctx, r, "U1", func(user *model.User) error {
+		moved := *status
+		moved.To = endOf(12)
+		return user.EditStatus(&moved, "U2")
+	})
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	if err := DeleteStatus(ctx, r, "U1", status.ID, "U1"); nil != err {
+		t.Fatal(err)
+	}
+
+	if err := r.Remove(ctx, "U1"); nil != err {
+		t.Fatal(err)
+	}
+
+	if _, err := r.Find(ctx, "U1"); err != ErrNotFound {
+		t.Errorf("Find after Remove: got %v, want ErrNotFound", err)
+	}
+
+	events, err := r.History(ctx, "U1")
+	if nil != err {
+		t.Fatalf("History after Remove: %v", err)
+	}
+
+	want := []model.EventType{model.StatusCreated, model.StatusEdited, model.StatusCancelled}
+	if len(events) != len(want) {
+		t.Fatalf("History returned %d events, want %d", len(events), len(want))
+	}
+
+	for i, event := range events {
+		if event.Type != want[i] || event.Status.ID != status.ID {
+			t.Errorf("event %d is %s of %s, want %s of %s", i, event.Type, event.Status.ID, want[i], status.ID)
+		}
+	}
+
+	if events[1].Author != "U2" || !events[1].Status.To.Equal(endOf(12)) {
+		t.Errorf("the edit is by %s until %s", events[1].Author, events[1].Status.To)
+	}
+}
+
+func testRebuild(t *testing.T, r Storage) {
+	ctx := context.Background()
+	status := model.NewStatus("", day(5), endOf(9), model.Vacation)
+	addUser(t, r, "U1", status)
+
+	// a snapshot saved without its events, as a buggy version could
+	err := Update(ctx, r, "U1", func(user *model.User) error {
+		user.Statuses = nil
+		return nil
+	})
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	if err := Rebuild(ctx, r, "U1"); nil != err {
+		t.Fatal(err)
+	}
+
+	user, err := r.Find(ctx, "U1")
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	if len(user.Statuses) != 1 || user.Statuses[0].ID != status.ID {
+		t.Errorf("Rebuild left the statuses %v, want the one of the history", user.Statuses)
+	}
+
+	if err := Rebuild(ctx, r, "nobody"); err != ErrNotFound {
+		t.Errorf("Rebuild of an unknown user: got %v, want ErrNotFound", err)
+	}
+}
+
+func testDigests(t *testing.T, r Storage) {
+	ctx := context.Background()
+	if err := r.RemoveDigest(ctx, "C1"); err != ErrNotFound {
+		t.Errorf("RemoveDigest of an unknown channel: got %v, want ErrNotFound", err)
+	}
+
+	if err := r.DigestSent(ctx, "C1", day(5)); err != ErrNotFound {
+		t.Errorf("DigestSent of an unknown channel: got %v, want ErrNotFound", err)
+	}
+
+	for _, digest := range []*model.Digest{
+		{Channel: "C1", Hour: 9, Minute: 0, Location: "UTC", Language: "en"},
+		{Channel: "C1", Hour: 10, Minute: 30, Location: "Europe/Berlin", Language: "de"},
+		{Channel: "C2", Hour: 8, Minute: 15, Location: "UTC", Language: "en"},
+	} {
+		if err := r.AddDigest(ctx, digest); nil != err {
+			t.Fatal(err)
+		}
+	}
+
+	sent := day(5).Add(9*time.Hour + 30*time.Minute)
+	if err := r.DigestSent(ctx, "C1", sent); nil != err {
+		t.Fatal(err)
+	}
+
+	if err := r.RemoveDigest(ctx, "C2"); nil != err {
+		t.Fatal(err)
+	}
+
+	digests, err := r.FindAllDigests(ctx)
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	if len(digests) != 1 {
+		t.Fatalf("FindAllDigests returned %d digests, want 1", len(digests))
+	}
+
+	digest := digests[0]
+	if digest.Channel != "C1" || digest.Hour != 10 || digest.Minute != 30 || digest.Location != "Europe/Berlin" ||
+		digest.Language != "de" || !digest.LastSent.Equal(sent) {
+		t.Errorf("digest is %+v", digest)
+	}
+}
@@ -0,0 +1,579 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/italolelis/hellowork/model"
+	"github.com/mattn/go-sqlite3"
+)
+
+// sqliteMigrations are applied in order on top of each other. The index of
+// the last applied one is kept in the user_version pragma, so never change or
+// reorder a released migration, only append new ones
+var sqliteMigrations = []string{
+	`CREATE TABLE users (
+		id       TEXT PRIMARY KEY,
+		username TEXT NOT NULL DEFAULT '',
+		location TEXT NOT NULL DEFAULT ''
+	);
+	CREATE TABLE statuses (
+		id          INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id     TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+		description TEXT NOT NULL DEFAULT '',
+		reason      TEXT NOT NULL,
+		from_at     INTEGER NOT NULL,
+		to_at       INTEGER NOT NULL
+	);
+	CREATE INDEX statuses_user_id ON statuses(user_id);
+	CREATE INDEX statuses_period ON statuses(from_at, to_at);`,
+	`ALTER TABLE users ADD COLUMN version INTEGER NOT NULL DEFAULT 0;`,
+	// the statuses saved before the history existed are given an ID and a
+	// made up creation event, so replaying the history keeps them
+	`ALTER TABLE statuses ADD COLUMN status_id TEXT NOT NULL DEFAULT '';
+	UPDATE statuses SET status_id = 'legacy' || id;
+	CREATE TABLE events (
+		id          INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id     TEXT NOT NULL,
+		type        TEXT NOT NULL,
+		author      TEXT NOT NULL DEFAULT '',
+		at          INTEGER NOT NULL,
+		status_id   TEXT NOT NULL,
+		description TEXT NOT NULL DEFAULT '',
+		reason      TEXT NOT NULL DEFAULT '',
+		from_at     INTEGER NOT NULL,
+		to_at       INTEGER NOT NULL
+	);
+	CREATE INDEX events_user_id ON events(user_id, id);
+	INSERT INTO events (user_id, type, author, at, status_id, description, reason, from_at, to_at)
+		SELECT user_id, 'status_created', user_id, CAST(strftime('%s', 'now') AS INTEGER) * 1000000000, status_id, description, reason, from_at, to_at
+		FROM statuses ORDER BY id;`,
+	`ALTER TABLE statuses ADD COLUMN channels TEXT NOT NULL DEFAULT '';
+	ALTER TABLE events ADD COLUMN channels TEXT NOT NULL DEFAULT '';`,
+	`CREATE TABLE profiles (
+		user_id   TEXT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+		token     TEXT NOT NULL,
+		status_id TEXT NOT NULL DEFAULT '',
+		until_at  INTEGER NOT NULL DEFAULT 0,
+		text      TEXT NOT NULL DEFAULT '',
+		emoji     TEXT NOT NULL DEFAULT ''
+	);`,
+	`CREATE TABLE digests (
+		channel   TEXT PRIMARY KEY,
+		hour      INTEGER NOT NULL,
+		minute    INTEGER NOT NULL,
+		location  TEXT NOT NULL DEFAULT '',
+		language  TEXT NOT NULL DEFAULT '',
+		last_sent INTEGER NOT NULL DEFAULT 0
+	);`,
+}
+
+// SQLite is a Repository backed by a SQLite database. Times are stored as
+// unix nanoseconds, so the half-day boundaries survive the round trip and
+// the date comparisons happen in the database
+type SQLite struct {
+	db *sql.DB
+}
+
+// NewSQLite opens the database at dsn, such as "hellowork.db", and brings its
+// schema up to date
+func NewSQLite(dsn string) (*SQLite, error) {
+	db, err := sql.Open("sqlite3", dsn)
+	if nil != err {
+		return nil, err
+	}
+
+	// SQLite allows a single writer, more connections only buy "database is
+	// locked" errors
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec("PRAGMA foreign_keys = ON"); nil != err {
+		db.Close()
+		return nil, err
+	}
+
+	if err := migrateSQLite(db); nil != err {
+		db.Close()
+		return nil, err
+	}
+
+	return &SQLite{db}, nil
+}
+
+// migrateSQLite applies the migrations the database hasn't seen yet
+func migrateSQLite(db *sql.DB) error {
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); nil != err {
+		return err
+	}
+
+	for i := version; i < len(sqliteMigrations); i++ {
+		tx, err := db.Begin()
+		if nil != err {
+			return err
+		}
+
+		if _, err := tx.Exec(sqliteMigrations[i]); nil != err {
+			tx.Rollback()
+			return err
+		}
+
+		// pragmas don't take placeholders, i is our own counter anyway
+		if _, err := tx.Exec("PRAGMA user_version = " + strconv.Itoa(i+1)); nil != err {
+			tx.Rollback()
+			return err
+		}
+
+		if err := tx.Commit(); nil != err {
+			return err
+		}
+
+		log.Debugf("SQLite schema migrated to version %d", i+1)
+	}
+
+	return nil
+}
+
+// Close closes the database
+func (r *SQLite) Close() error {
+	return r.db.Close()
+}
+
+func (r *SQLite) Find(ctx context.Context, id string) (*model.User, error) {
+	users, err := r.findUsers(ctx, "SELECT id, username, location, version FROM users WHERE id = ?", id)
+	if nil != err {
+		return nil, err
+	}
+
+	if len(users) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return users[0], nil
+}
+
+func (r *SQLite) FindAll(ctx context.Context) ([]*model.User, error) {
+	return r.findUsers(ctx, "SELECT id, username, location, version FROM users ORDER BY id")
+}
+
+func (r *SQLite) FindAllOut(ctx context.Context, date time.Time) ([]*model.User, error) {
+	return r.FindAllOutBetween(ctx, date, date)
+}
+
+func (r *SQLite) FindAllOutBetween(ctx context.Context, from time.Time, to time.Time) ([]*model.User, error) {
+	return r.findUsers(ctx, `SELECT id, username, location, version FROM users WHERE id IN (
+		SELECT user_id FROM statuses WHERE from_at <= ? AND to_at >= ?
+	) ORDER BY id`, to.UnixNano(), from.UnixNano())
+}
+
+func (r *SQLite) FindAllByID(ctx context.Context, ids []string, date time.Time) ([]*model.User, error) {
+	var users []*model.User
+
+	for _, id := range ids {
+		user, err := r.Find(ctx, id)
+		switch {
+		case err == ErrNotFound:
+			continue
+		case nil != err:
+			return nil, err
+		}
+
+		if user.IsOutDuring(date, date) {
+			users = append(users, user)
+		}
+	}
+
+	return users, nil
+}
+
+// Add stores the user along with all of its statuses, replacing the ones
+// stored before
+func (r *SQLite) Add(ctx context.Context, user *model.User) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if nil != err {
+		return sqliteError(err)
+	}
+
+	if err := addSQLiteUser(ctx, tx, user); nil != err {
+		tx.Rollback()
+		return sqliteError(err)
+	}
+
+	if err := tx.Commit(); nil != err {
+		return sqliteError(err)
+	}
+
+	user.ClearChanges()
+	user.Version++
+
+	return nil
+}
+
+func addSQLiteUser(ctx context.Context, tx *sql.Tx, user *model.User) error {
+	var version int
+	err := tx.QueryRowContext(ctx, "SELECT version FROM users WHERE id = ?", string(user.ID)).Scan(&version)
+	if nil != err && err != sql.ErrNoRows {
+		return err
+	}
+
+	if version != user.Version {
+		return ErrConflict
+	}
+
+	if err := checkSQLiteTimes(user); nil != err {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT OR IGNORE INTO users (id) VALUES (?)", string(user.ID))
+	if nil != err {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE users SET username = ?, location = ?, version = ? WHERE id = ?", user.Username, user.Location, user.Version+1, string(user.ID))
+	if nil != err {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM statuses WHERE user_id = ?", string(user.ID)); nil != err {
+		return err
+	}
+
+	for _, status := range user.Statuses {
+		_, err := tx.ExecContext(
+			ctx,
+			"INSERT INTO statuses (user_id, status_id, description, reason, from_at, to_at, channels) VALUES (?, ?, ?, ?, ?, ?, ?)",
+			string(user.ID), string(status.ID), status.Description, string(status.Reason), toUnixNano(status.From), toUnixNano(status.To),
+			strings.Join(status.Channels, ","),
+		)
+		if nil != err {
+			return err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM profiles WHERE user_id = ?", string(user.ID)); nil != err {
+		return err
+	}
+
+	if profile := user.Profile; nil != profile {
+		_, err := tx.ExecContext(
+			ctx,
+			"INSERT INTO profiles (user_id, token, status_id, until_at, text, emoji) VALUES (?, ?, ?, ?, ?, ?)",
+			string(user.ID), profile.Token, string(profile.Status), toUnixNano(profile.Until), profile.Text, profile.Emoji,
+		)
+		if nil != err {
+			return err
+		}
+	}
+
+	for _, event := range user.Changes() {
+		_, err := tx.ExecContext(
+			ctx,
+			"INSERT INTO events (user_id, type, author, at, status_id, description, reason, from_at, to_at, channels) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			string(user.ID), string(event.Type), string(event.Author), toUnixNano(event.At), string(event.Status.ID),
+			event.Status.Description, string(event.Status.Reason), toUnixNano(event.Status.From), toUnixNano(event.Status.To),
+			strings.Join(event.Status.Channels, ","),
+		)
+		if nil != err {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *SQLite) Remove(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM users WHERE id = ?", id)
+	if nil != err {
+		return sqliteError(err)
+	}
+
+	if n, err := result.RowsAffected(); nil == err && n == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// findUsers loads the users selected by the query together with their
+// statuses
+func (r *SQLite) findUsers(ctx context.Context, query string, args ...interface{}) ([]*model.User, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if nil != err {
+		return nil, sqliteError(err)
+	}
+
+	var users []*model.User
+	for rows.Next() {
+		user := model.NewUser("")
+		if err := rows.Scan(&user.ID, &user.Username, &user.Location, &user.Version); nil != err {
+			rows.Close()
+			return nil, sqliteError(err)
+		}
+
+		users = append(users, user)
+	}
+
+	rows.Close()
+	if err := rows.Err(); nil != err {
+		return nil, sqliteError(err)
+	}
+
+	for _, user := range users {
+		if err := r.loadStatuses(ctx, user); nil != err {
+			return nil, sqliteError(err)
+		}
+
+		if err := r.loadProfile(ctx, user); nil != err {
+			return nil, sqliteError(err)
+		}
+	}
+
+	return users, nil
+}
+
+// loadStatuses fills in the statuses of the user in the order they were
+// added, so the latest one stays last
+func (r *SQLite) loadStatuses(ctx context.Context, user *model.User) error {
+	rows, err := r.db.QueryContext(ctx, "SELECT status_id, description, reason, from_at, to_at, channels FROM statuses WHERE user_id = ? ORDER BY id", string(user.ID))
+	if nil != err {
+		return err
+	}
+	defer rows.Close()
+
+	loc := user.TimeLocation()
+	for rows.Next() {
+		var (
+			id, description, reason, channels string
+			from, to                          int64
+		)
+
+		if err := rows.Scan(&id, &description, &reason, &from, &to, &channels); nil != err {
+			return err
+		}
+
+		status := model.NewStatus(description, fromUnixNano(from, loc), fromUnixNano(to, loc), model.Reason(reason))
+		status.ID = model.StatusID(id)
+		status.Channels = splitChannels(channels)
+		user.Statuses = append(user.Statuses, status)
+	}
+
+	return rows.Err()
+}
+
+// loadProfile fills in how the user's slack profile is synced, if it is
+func (r *SQLite) loadProfile(ctx context.Context, user *model.User) error {
+	var (
+		profile  model.ProfileSync
+		statusID string
+		until    int64
+	)
+
+	err := r.db.QueryRowContext(ctx, "SELECT token, status_id, until_at, text, emoji FROM profiles WHERE user_id = ?", string(user.ID)).
+		Scan(&profile.Token, &statusID, &until, &profile.Text, &profile.Emoji)
+	switch {
+	case err == sql.ErrNoRows:
+		return nil
+	case nil != err:
+		return err
+	}
+
+	profile.Status = model.StatusID(statusID)
+	profile.Until = fromUnixNano(until, user.TimeLocation())
+	user.Profile = &profile
+
+	return nil
+}
+
+func (r *SQLite) History(ctx context.Context, id string) ([]*model.Event, error) {
+	user, err := r.Find(ctx, id)
+	if nil != err && err != ErrNotFound {
+		return nil, err
+	}
+
+	loc := time.Local
+	if nil != user {
+		loc = user.TimeLocation()
+	}
+
+	rows, err := r.db.QueryContext(
+		ctx,
+		"SELECT type, author, at, status_id, description, reason, from_at, to_at, channels FROM events WHERE user_id = ? ORDER BY id",
+		id,
+	)
+	if nil != err {
+		return nil, sqliteError(err)
+	}
+	defer rows.Close()
+
+	var events []*model.Event
+	for rows.Next() {
+		var (
+			eventType, author, statusID, description, reason, channels string
+			at, from, to                                               int64
+		)
+
+		if err := rows.Scan(&eventType, &author, &at, &statusID, &description, &reason, &from, &to, &channels); nil != err {
+			return nil, sqliteError(err)
+		}
+
+		status := model.NewStatus(description, fromUnixNano(from, loc), fromUnixNano(to, loc), model.Reason(reason))
+		status.ID = model.StatusID(statusID)
+		status.Channels = splitChannels(channels)
+		events = append(events, &model.Event{
+			Type:   model.EventType(eventType),
+			User:   model.UserID(id),
+			Author: model.UserID(author),
+			At:     fromUnixNano(at, loc),
+			Status: *status,
+		})
+	}
+
+	if err := rows.Err(); nil != err {
+		return nil, sqliteError(err)
+	}
+
+	if nil == user && len(events) == 0 {
+		return nil, ErrNotFound
+	}
+
+	return events, nil
+}
+
+func (r *SQLite) FindAllDigests(ctx context.Context) ([]*model.Digest, error) {
+	rows, err := r.db.QueryContext(ctx, "SELECT channel, hour, minute, location, language, last_sent FROM digests ORDER BY channel")
+	if nil != err {
+		return nil, sqliteError(err)
+	}
+	defer rows.Close()
+
+	var digests []*model.Digest
+	for rows.Next() {
+		var (
+			digest   model.Digest
+			lastSent int64
+		)
+
+		if err := rows.Scan(&digest.Channel, &digest.Hour, &digest.Minute, &digest.Location, &digest.Language, &lastSent); nil != err {
+			return nil, sqliteError(err)
+		}
+
+		digest.LastSent = fromUnixNano(lastSent, digest.TimeLocation())
+		digests = append(digests, &digest)
+	}
+
+	if err := rows.Err(); nil != err {
+		return nil, sqliteError(err)
+	}
+
+	return digests, nil
+}
+
+func (r *SQLite) AddDigest(ctx context.Context, digest *model.Digest) error {
+	_, err := r.db.ExecContext(
+		ctx,
+		"INSERT OR REPLACE INTO digests (channel, hour, minute, location, language, last_sent) VALUES (?, ?, ?, ?, ?, ?)",
+		digest.Channel, digest.Hour, digest.Minute, digest.Location, digest.Language, toUnixNano(digest.LastSent),
+	)
+	if nil != err {
+		return sqliteError(err)
+	}
+
+	return nil
+}
+
+func (r *SQLite) RemoveDigest(ctx context.Context, channel string) error {
+	return r.execDigest(ctx, "DELETE FROM digests WHERE channel = ?", channel)
+}
+
+func (r *SQLite) DigestSent(ctx context.Context, channel string, at time.Time) error {
+	return r.execDigest(ctx, "UPDATE digests SET last_sent = ? WHERE channel = ?", toUnixNano(at), channel)
+}
+
+// execDigest runs a statement on a single digest, returning ErrNotFound when
+// it touched none
+func (r *SQLite) execDigest(ctx context.Context, query string, args ...interface{}) error {
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if nil != err {
+		return sqliteError(err)
+	}
+
+	if n, err := result.RowsAffected(); nil == err && n == 0 {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// sqliteError tells a busy or locked database and an expired context, which
+// may go away by retrying, from the rest
+func sqliteError(err error) error {
+	switch err {
+	case ErrConflict:
+		return err
+	case context.DeadlineExceeded, context.Canceled:
+		return transient(err)
+	}
+
+	if serr, ok := err.(sqlite3.Error); ok && (serr.Code == sqlite3.ErrBusy || serr.Code == sqlite3.ErrLocked) {
+		return transient(err)
+	}
+
+	return permanent(err)
+}
+
+// splitChannels reads back the comma separated channels of a status
+func splitChannels(channels string) []string {
+	if len(channels) == 0 {
+		return nil
+	}
+
+	return strings.Split(channels, ",")
+}
+
+// checkSQLiteTimes refuses the times of the user that don't fit in unix
+// nanoseconds, rather than storing them wrapped around
+func checkSQLiteTimes(user *model.User) error {
+	var times []time.Time
+	for _, status := range user.Statuses {
+		times = append(times, status.From, status.To)
+	}
+
+	for _, event := range user.Changes() {
+		times = append(times, event.At, event.Status.From, event.Status.To)
+	}
+
+	if nil != user.Profile {
+		times = append(times, user.Profile.Until)
+	}
+
+	for _, t := range times {
+		if t.After(model.LatestDate) {
+			return fmt.Errorf("%s is after the last date SQLite can store", t.Format(time.RFC3339))
+		}
+	}
+
+	return nil
+}
+
+// toUnixNano stores the zero time, the end of a status that is still
+// waiting for an until, as 0 since it doesn't fit in nanoseconds
+func toUnixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+
+	return t.UnixNano()
+}
+
+func fromUnixNano(n int64, loc *time.Location) time.Time {
+	if n == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(0, n).In(loc)
+}
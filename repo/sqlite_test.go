@@ -0,0 +1,49 @@
+package repo
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/italolelis/hellowork/model"
+)
+
+// newTestSQLite opens a SQLite repository in a temporary directory. The
+// returned func closes it and removes the directory
+func newTestSQLite(t testing.TB) (*SQLite, func()) {
+	dir, err := ioutil.TempDir("", "hellowork")
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	r, err := NewSQLite(filepath.Join(dir, "hellowork.db"))
+	if nil != err {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+
+	return r, func() {
+		r.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestSQLiteRefusesTimesAfterLatestDate(t *testing.T) {
+	ctx := context.Background()
+	r, done := newTestSQLite(t)
+	defer done()
+
+	from := time.Date(2999, time.December, 1, 0, 0, 0, 0, time.UTC)
+	user := model.NewUser("far")
+	user.AddStatus(model.NewStatus("", from, from.AddDate(1, 0, 0), model.Vacation), "")
+	if err := r.Add(ctx, user); nil == err || IsTransient(err) {
+		t.Fatalf("Add stored a status in %d, got %v", from.Year(), err)
+	}
+
+	if _, err := r.Find(ctx, "far"); err != ErrNotFound {
+		t.Errorf("Find after a refused Add: got %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,49 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/italolelis/hellowork/model"
+)
+
+// maxConflictRetries is how many times a change is tried on a user that
+// keeps being saved by somebody else
+const maxConflictRetries = 3
+
+// Update loads the user, applies the change and saves it, starting over when
+// somebody else saved the user in the meantime. An error returned by the
+// change is returned as is
+func Update(ctx context.Context, r Repository, id string, change func(user *model.User) error) error {
+	for attempt := 1; ; attempt++ {
+		user, err := r.Find(ctx, id)
+		if nil != err {
+			return err
+		}
+
+		if err := change(user); nil != err {
+			return err
+		}
+
+		err = r.Add(ctx, user)
+		if err != ErrConflict || attempt == maxConflictRetries {
+			return err
+		}
+	}
+}
+
+// UpdateStatus replaces a single status of the user, the one with the same
+// ID. It returns model.ErrStatusNotFound when the user has no such status
+func UpdateStatus(ctx context.Context, r Repository, id string, status *model.Status, author model.UserID) error {
+	return Update(ctx, r, id, func(user *model.User) error {
+		return user.EditStatus(status, author)
+	})
+}
+
+// DeleteStatus cancels a single status of the user, which stays in its
+// history. It returns model.ErrStatusNotFound when the user has no such
+// status
+func DeleteStatus(ctx context.Context, r Repository, id string, statusID model.StatusID, author model.UserID) error {
+	return Update(ctx, r, id, func(user *model.User) error {
+		return user.CancelStatus(statusID, author)
+	})
+}
@@ -0,0 +1,28 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/italolelis/hellowork/model"
+)
+
+// Watched is a storage that tells whenever a user was saved through it, so
+// whoever acts on the statuses can look at them again instead of polling
+type Watched struct {
+	Storage
+	changed func()
+}
+
+// NewWatched calls changed after every user successfully saved
+func NewWatched(storage Storage, changed func()) *Watched {
+	return &Watched{storage, changed}
+}
+
+func (w *Watched) Add(ctx context.Context, user *model.User) error {
+	if err := w.Storage.Add(ctx, user); nil != err {
+		return err
+	}
+
+	w.changed()
+	return nil
+}
@@ -0,0 +1,35 @@
+package repo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/italolelis/hellowork/model"
+)
+
+func TestWatchedTellsAboutSavedUsers(t *testing.T) {
+	ctx := context.Background()
+	changes := 0
+	r := NewWatched(NewInMemory(), func() { changes++ })
+
+	user := model.NewUser("U1")
+	user.AddStatus(model.NewStatus("", day(5), endOf(9), model.Vacation), "U1")
+	if err := r.Add(ctx, user); nil != err {
+		t.Fatal(err)
+	}
+
+	// a stale copy isn't saved
+	stale := model.NewUser("U1")
+	stale.AddStatus(model.NewStatus("", day(6), endOf(7), model.Sick), "U1")
+	if err := r.Add(ctx, stale); err != ErrConflict {
+		t.Fatalf("got %v, want ErrConflict", err)
+	}
+
+	if err := r.Remove(ctx, "U1"); nil != err {
+		t.Fatal(err)
+	}
+
+	if changes != 1 {
+		t.Errorf("told about %d changes, want 1", changes)
+	}
+}
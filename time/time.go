@@ -22,6 +22,14 @@ const (
 	secondsInMonth    = 2678400
 )
 
+// The week is considered to start on monday and to end on sunday, with
+// saturday and sunday being the weekend
+var (
+	weekStartsAt = time.Monday
+	weekEndsAt   = time.Sunday
+	weekendDays  = []time.Weekday{time.Saturday, time.Sunday}
+)
+
 // Represents the different string formats for dates
 const (
 	DefaultFormat       = "2006-01-02 15:04:05"
@@ -76,10 +84,11 @@ const maxNSecs = 999999999
 
 // NowInLocation returns a new Carbon instance for right now in given location.
 // The location is in IANA Time Zone database, such as "America/New_York".
+// If the location is invalid, it returns now in the local time zone and an error.
 func NowInLocation(loc string) (time.Time, error) {
 	l, err := time.LoadLocation(loc)
 	if err != nil {
-		return nowIn(l), err
+		return time.Now(), err
 	}
 	return nowIn(l), nil
 }
@@ -205,6 +214,29 @@ func AddWeek(t time.Time) time.Time {
 	return AddWeeks(t, 1)
 }
 
+// AddWeekdays adds weekdays to the current time, skipping weekend days.
+// Positive value travels forward while negative value travels into the past.
+func AddWeekdays(t time.Time, wd int) time.Time {
+	d := 1
+	if wd < 0 {
+		wd, d = -wd, -d
+	}
+
+	for wd > 0 {
+		t = AddDays(t, d)
+		if IsWeekday(t) {
+			wd--
+		}
+	}
+
+	return t
+}
+
+// AddWeekday adds a weekday to the current time
+func AddWeekday(t time.Time) time.Time {
+	return AddWeekdays(t, 1)
+}
+
 // AddHours adds an hour to the current time.
 // Positive value travels forward while negative value travels into the past
 func AddHours(t time.Time, h int) time.Time {
@@ -313,25 +345,25 @@ func SubDays(t time.Time, d int) time.Time {
 	return AddDays(t, -d)
 }
 
-// // SubWeekday removes a weekday from the current time
-// func (c *Carbon) SubWeekday() *Carbon {
-// 	return c.SubWeekdays(1)
-// }
+// SubWeekday removes a weekday from the current time
+func SubWeekday(t time.Time) time.Time {
+	return SubWeekdays(t, 1)
+}
 
-// // SubWeekdays removes a weekday from the current time
-// func (c *Carbon) SubWeekdays(wd int) *Carbon {
-// 	return c.AddWeekdays(-wd)
-// }
+// SubWeekdays removes weekdays from the current time
+func SubWeekdays(t time.Time, wd int) time.Time {
+	return AddWeekdays(t, -wd)
+}
 
-// // SubWeek removes a week from the current time
-// func (c *Carbon) SubWeek() *Carbon {
-// 	return c.SubWeeks(1)
-// }
+// SubWeek removes a week from the current time
+func SubWeek(t time.Time) time.Time {
+	return SubWeeks(t, 1)
+}
 
-// // SubWeeks removes weeks to the current time
-// func (c *Carbon) SubWeeks(w int) *Carbon {
-// 	return c.AddWeeks(-w)
-// }
+// SubWeeks removes weeks to the current time
+func SubWeeks(t time.Time, w int) time.Time {
+	return AddWeeks(t, -w)
+}
 
 // // SubHour removes an hour from the current time
 // func (c *Carbon) SubHour() *Carbon {
@@ -443,22 +475,22 @@ func SubDays(t time.Time, d int) time.Time {
 // 	return c.Format(RFC3339Format)
 // }
 
-// // IsWeekday determines if the current time is a weekday
-// func (c *Carbon) IsWeekday() bool {
-// 	return !c.IsWeekend()
-// }
+// IsWeekday determines if the current time is a weekday
+func IsWeekday(t time.Time) bool {
+	return !IsWeekend(t)
+}
 
-// // IsWeekend determines if the current time is a weekend day
-// func (c *Carbon) IsWeekend() bool {
-// 	d := c.Weekday()
-// 	for _, wd := range c.WeekendDays() {
-// 		if d == wd {
-// 			return true
-// 		}
-// 	}
+// IsWeekend determines if the current time is a weekend day
+func IsWeekend(t time.Time) bool {
+	d := t.Weekday()
+	for _, wd := range weekendDays {
+		if d == wd {
+			return true
+		}
+	}
 
-// 	return false
-// }
+	return false
+}
 
 // // IsYesterday determines if the current time is yesterday
 // func (c *Carbon) IsYesterday() bool {
@@ -1082,15 +1114,20 @@ func absValue(needsAbs bool, value int64) int64 {
 // 	return c.Translator.chooseTrans(transID, t), nil
 // }
 
-// // StartOfDay returns the time at 00:00:00 of the same day
-// func (c *Carbon) StartOfDay() *Carbon {
-// 	return create(c.Year(), c.Month(), c.Day(), 0, 0, 0, 0, c.Location())
-// }
+// StartOfDay returns the time at 00:00:00 of the same day
+func StartOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
 
-// // EndOfDay returns the time at 23:59:59 of the same day
-// func (c *Carbon) EndOfDay() *Carbon {
-// 	return create(c.Year(), c.Month(), c.Day(), 23, 59, 59, maxNSecs, c.Location())
-// }
+// Midday returns the time at 12:00:00 of the same day
+func Midday(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 12, 0, 0, 0, t.Location())
+}
+
+// EndOfDay returns the time at 23:59:59 of the same day
+func EndOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 23, 59, 59, maxNSecs, t.Location())
+}
 
 // // StartOfMonth returns the date on the first day of the month and the time to 00:00:00
 // func (c *Carbon) StartOfMonth() *Carbon {
@@ -1152,62 +1189,70 @@ func EndOfMonth(t time.Time) time.Time {
 // 	return create(year, time.December, 31, 23, 59, 59, maxNSecs, c.Location())
 // }
 
-// // StartOfWeek returns the date of the first day of week at 00:00:00
-// func (c *Carbon) StartOfWeek(t time.Time) time.Time {
-// 	return Previous(t, WeekStartsAt(t))
-// }
+// StartOfWeek returns the date of the first day of week at 00:00:00
+func StartOfWeek(t time.Time) time.Time {
+	if t.Weekday() == weekStartsAt {
+		return StartOfDay(t)
+	}
 
-// // EndOfWeek returns the date of the last day of the week at 23:59:59
-// func EndOfWeek(t time.Time) time.Time {
-// 	return EndOfDay(Next(t, WeekEndsAt(t)))
-// }
+	return Previous(t, weekStartsAt)
+}
 
-// // Next changes the time to the next occurrence of a given day of the week
-// func Next(t time.Time, wd time.Weekday) time.Time {
-// 	t = AddDay(t)
-// 	for t.Weekday() != wd {
-// 		t = AddDay(t)
-// 	}
+// EndOfWeek returns the date of the last day of the week at 23:59:59
+func EndOfWeek(t time.Time) time.Time {
+	if t.Weekday() == weekEndsAt {
+		return EndOfDay(t)
+	}
 
-// 	return StartOfDay(t)
-// }
+	return EndOfDay(Next(t, weekEndsAt))
+}
 
-// // NextWeekday goes forward to the next weekday
-// func NextWeekday(t time.Time) time.Time {
-// 	return AddWeekday(t)
-// }
+// Next changes the time to the next occurrence of a given day of the week
+func Next(t time.Time, wd time.Weekday) time.Time {
+	t = AddDay(t)
+	for t.Weekday() != wd {
+		t = AddDay(t)
+	}
 
-// // PreviousWeekday goes back to the previous weekday
-// func PreviousWeekday(t time.Time) time.Time {
-// 	return SubWeekday(t)
-// }
+	return StartOfDay(t)
+}
 
-// // NextWeekendDay goes forward to the next weekend day
-// func NextWeekendDay(t time.Time) time.Time {
-// 	t = AddDay(t)
-// 	for !IsWeekend(t) {
-// 		t = AddDay(t)
-// 	}
+// NextWeekday goes forward to the next weekday
+func NextWeekday(t time.Time) time.Time {
+	return AddWeekday(t)
+}
 
-// 	return c
-// }
+// PreviousWeekday goes back to the previous weekday
+func PreviousWeekday(t time.Time) time.Time {
+	return SubWeekday(t)
+}
 
-// // PreviousWeekendDay goes back to the previous weekend day
-// func PreviousWeekendDay(t time.Time) time.Time {
-// 	t = SubDay(t)
-// 	for !IsWeekend(t) {
-// 		t = SubDay(t)
-// 	}
+// NextWeekendDay goes forward to the next weekend day
+func NextWeekendDay(t time.Time) time.Time {
+	t = AddDay(t)
+	for !IsWeekend(t) {
+		t = AddDay(t)
+	}
 
-// 	return t
-// }
+	return t
+}
 
-// // Previous changes the time to the previous occurrence of a given day of the week
-// func Previous(t time.Time, wd time.Weekday) time.Time {
-// 	t = SubDay(t)
-// 	for t.Weekday() != wd {
-// 		t = SubDay(t)
-// 	}
+// PreviousWeekendDay goes back to the previous weekend day
+func PreviousWeekendDay(t time.Time) time.Time {
+	t = SubDay(t)
+	for !IsWeekend(t) {
+		t = SubDay(t)
+	}
 
-// 	return StartOfDay(t)
-// }
+	return t
+}
+
+// Previous changes the time to the previous occurrence of a given day of the week
+func Previous(t time.Time, wd time.Weekday) time.Time {
+	t = SubDay(t)
+	for t.Weekday() != wd {
+		t = SubDay(t)
+	}
+
+	return StartOfDay(t)
+}
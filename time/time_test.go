@@ -0,0 +1,155 @@
+package time
+
+import (
+	"testing"
+	"time"
+)
+
+// layout shows the zone, so the tests see which side of a change of the
+// clocks a time is on
+const layout = "Mon 2006-01-02 15:04:05.999999999 MST"
+
+// in reads a time such as "2018-03-23 09:00" in Berlin, where the clocks
+// go forward on the 25th of March 2018 and back on the 28th of October
+func in(t *testing.T, value string) time.Time {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	parsed, err := time.ParseInLocation("2006-01-02 15:04", value, berlin)
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	return parsed
+}
+
+func TestAddWeekdays(t *testing.T) {
+	cases := []struct {
+		from string
+		wd   int
+		want string
+	}{
+		{"2018-03-14 09:00", 0, "Wed 2018-03-14 09:00:00 CET"},
+		{"2018-03-14 09:00", 1, "Thu 2018-03-15 09:00:00 CET"},
+		{"2018-03-16 09:00", 1, "Mon 2018-03-19 09:00:00 CET"},
+		{"2018-03-15 09:00", 2, "Mon 2018-03-19 09:00:00 CET"},
+		{"2018-03-14 09:00", 5, "Wed 2018-03-21 09:00:00 CET"},
+		{"2018-03-17 09:00", 1, "Mon 2018-03-19 09:00:00 CET"},
+		{"2018-03-18 09:00", 1, "Mon 2018-03-19 09:00:00 CET"},
+		{"2018-03-19 09:00", -1, "Fri 2018-03-16 09:00:00 CET"},
+		{"2018-03-17 09:00", -1, "Fri 2018-03-16 09:00:00 CET"},
+		{"2018-03-20 09:00", -2, "Fri 2018-03-16 09:00:00 CET"},
+		// the wall clock stays the same over the changes of the clocks
+		{"2018-03-23 09:00", 1, "Mon 2018-03-26 09:00:00 CEST"},
+		{"2018-03-26 09:00", -1, "Fri 2018-03-23 09:00:00 CET"},
+		{"2018-10-26 23:30", 1, "Mon 2018-10-29 23:30:00 CET"},
+	}
+
+	for _, c := range cases {
+		if got := AddWeekdays(in(t, c.from), c.wd).Format(layout); got != c.want {
+			t.Errorf("%s %+d weekdays: got %s, want %s", c.from, c.wd, got, c.want)
+		}
+
+		if got := SubWeekdays(in(t, c.from), -c.wd).Format(layout); got != c.want {
+			t.Errorf("%s %+d weekdays back: got %s, want %s", c.from, -c.wd, got, c.want)
+		}
+	}
+}
+
+func TestStartAndEndOfWeek(t *testing.T) {
+	cases := []struct {
+		date  string
+		start string
+		end   string
+	}{
+		{"2018-03-14 09:00", "Mon 2018-03-12 00:00:00 CET", "Sun 2018-03-18 23:59:59.999999999 CET"},
+		{"2018-03-12 00:00", "Mon 2018-03-12 00:00:00 CET", "Sun 2018-03-18 23:59:59.999999999 CET"},
+		{"2018-03-17 15:00", "Mon 2018-03-12 00:00:00 CET", "Sun 2018-03-18 23:59:59.999999999 CET"},
+		{"2018-03-18 23:00", "Mon 2018-03-12 00:00:00 CET", "Sun 2018-03-18 23:59:59.999999999 CET"},
+		{"2018-03-25 12:00", "Mon 2018-03-19 00:00:00 CET", "Sun 2018-03-25 23:59:59.999999999 CEST"},
+		{"2018-10-24 12:00", "Mon 2018-10-22 00:00:00 CEST", "Sun 2018-10-28 23:59:59.999999999 CET"},
+		{"2018-12-31 12:00", "Mon 2018-12-31 00:00:00 CET", "Sun 2019-01-06 23:59:59.999999999 CET"},
+	}
+
+	for _, c := range cases {
+		if got := StartOfWeek(in(t, c.date)).Format(layout); got != c.start {
+			t.Errorf("start of the week of %s: got %s, want %s", c.date, got, c.start)
+		}
+
+		if got := EndOfWeek(in(t, c.date)).Format(layout); got != c.end {
+			t.Errorf("end of the week of %s: got %s, want %s", c.date, got, c.end)
+		}
+	}
+}
+
+func TestMidday(t *testing.T) {
+	cases := []struct {
+		date  string
+		want  string
+		hours time.Duration
+	}{
+		{"2018-03-14 09:00", "Wed 2018-03-14 12:00:00 CET", 12},
+		{"2018-03-14 23:59", "Wed 2018-03-14 12:00:00 CET", 12},
+		// the first and the last day of summer time are 23 and 25 hours long
+		{"2018-03-25 01:00", "Sun 2018-03-25 12:00:00 CEST", 11},
+		{"2018-10-28 01:00", "Sun 2018-10-28 12:00:00 CET", 13},
+	}
+
+	for _, c := range cases {
+		date := in(t, c.date)
+		got := Midday(date)
+		if got.Format(layout) != c.want {
+			t.Errorf("midday of %s: got %s, want %s", c.date, got.Format(layout), c.want)
+		}
+
+		if elapsed := got.Sub(StartOfDay(date)); elapsed != c.hours*time.Hour {
+			t.Errorf("midday of %s is %s after the start of the day", c.date, elapsed)
+		}
+	}
+}
+
+func TestNextAndPrevious(t *testing.T) {
+	cases := []struct {
+		date     string
+		wd       time.Weekday
+		next     string
+		previous string
+	}{
+		{"2018-03-14 09:00", time.Friday, "Fri 2018-03-16 00:00:00 CET", "Fri 2018-03-09 00:00:00 CET"},
+		{"2018-03-14 09:00", time.Wednesday, "Wed 2018-03-21 00:00:00 CET", "Wed 2018-03-07 00:00:00 CET"},
+		{"2018-03-16 18:00", time.Monday, "Mon 2018-03-19 00:00:00 CET", "Mon 2018-03-12 00:00:00 CET"},
+		{"2018-03-17 09:00", time.Sunday, "Sun 2018-03-18 00:00:00 CET", "Sun 2018-03-11 00:00:00 CET"},
+		{"2018-03-23 09:00", time.Monday, "Mon 2018-03-26 00:00:00 CEST", "Mon 2018-03-19 00:00:00 CET"},
+		{"2018-10-29 09:00", time.Friday, "Fri 2018-11-02 00:00:00 CET", "Fri 2018-10-26 00:00:00 CEST"},
+		{"2018-12-29 09:00", time.Tuesday, "Tue 2019-01-01 00:00:00 CET", "Tue 2018-12-25 00:00:00 CET"},
+	}
+
+	for _, c := range cases {
+		if got := Next(in(t, c.date), c.wd).Format(layout); got != c.next {
+			t.Errorf("next %s after %s: got %s, want %s", c.wd, c.date, got, c.next)
+		}
+
+		if got := Previous(in(t, c.date), c.wd).Format(layout); got != c.previous {
+			t.Errorf("previous %s before %s: got %s, want %s", c.wd, c.date, got, c.previous)
+		}
+	}
+}
+
+func TestIsWeekend(t *testing.T) {
+	for day, weekend := range map[string]bool{
+		"2018-03-16 23:59": false,
+		"2018-03-17 00:00": true,
+		"2018-03-18 23:59": true,
+		"2018-03-19 00:00": false,
+	} {
+		if got := IsWeekend(in(t, day)); got != weekend {
+			t.Errorf("%s: weekend is %t", day, got)
+		}
+
+		if got := IsWeekday(in(t, day)); got == weekend {
+			t.Errorf("%s: weekday is %t", day, got)
+		}
+	}
+}
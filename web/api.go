@@ -0,0 +1,401 @@
+package web
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/italolelis/hellowork/model"
+	"github.com/italolelis/hellowork/repo"
+	htime "github.com/italolelis/hellowork/time"
+)
+
+const (
+	// APIPath is where the API is served
+	APIPath = "/api/"
+	// OpenAPIPath is where the description of the API is served, without a
+	// token
+	OpenAPIPath = APIPath + "openapi.yaml"
+
+	defaultPageSize = 50
+	maxPageSize     = 200
+	apiTimeout      = 10 * time.Second
+	apiDateLayout   = "2006-01-02"
+)
+
+var errInvalidPeriod = errors.New("give either date, or from and to, as 2018-02-20 or 2018-02-20T09:00:00Z")
+
+// apiUser is how a user is shown, its profile token never leaves the
+// storage
+type apiUser struct {
+	ID        model.UserID `json:"id"`
+	Username  string       `json:"username"`
+	Location  string       `json:"location"`
+	Available bool         `json:"available"`
+}
+
+type apiStatus struct {
+	ID          model.StatusID `json:"id"`
+	Reason      model.Reason   `json:"reason"`
+	Description string         `json:"description"`
+	From        time.Time      `json:"from"`
+	// To is null while the status waits for when the user is back
+	To *time.Time `json:"to"`
+}
+
+type apiAbsence struct {
+	User     apiUser     `json:"user"`
+	Statuses []apiStatus `json:"statuses"`
+}
+
+type pagination struct {
+	Offset int `json:"offset"`
+	Limit  int `json:"limit"`
+	Total  int `json:"total"`
+}
+
+type page struct {
+	Data       interface{} `json:"data"`
+	Pagination pagination  `json:"pagination"`
+}
+
+type apiError struct {
+	Error string `json:"error"`
+}
+
+// API serves the users and their statuses as JSON, for dashboards and
+// scripts. Every request needs one of the tokens, sent as
+// "Authorization: Bearer <token>", and lists are paginated with the limit and
+// offset parameters
+type API struct {
+	repo   repo.Repository
+	tokens [][]byte
+	mux    *http.ServeMux
+}
+
+func NewAPI(repo repo.Repository, tokens []string) *API {
+	a := &API{repo: repo, mux: http.NewServeMux()}
+	for _, token := range tokens {
+		if token = strings.TrimSpace(token); len(token) > 0 {
+			a.tokens = append(a.tokens, []byte(token))
+		}
+	}
+
+	a.mux.HandleFunc(APIPath+"users", a.listUsers)
+	a.mux.HandleFunc(APIPath+"users/", a.showUser)
+	a.mux.HandleFunc(APIPath+"out", a.listOut)
+
+	return a
+}
+
+func (a *API) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeError(w, http.StatusMethodNotAllowed, http.StatusText(http.StatusMethodNotAllowed))
+		return
+	}
+
+	if r.URL.Path == OpenAPIPath {
+		w.Header().Set("Content-Type", "application/yaml; charset=utf-8")
+		w.Write([]byte(openAPISpec))
+		return
+	}
+
+	if !a.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="hellowork"`)
+		writeError(w, http.StatusUnauthorized, "missing or invalid token")
+		return
+	}
+
+	a.mux.ServeHTTP(w, r)
+}
+
+// authorized compares the token with every known one in constant time
+func (a *API) authorized(r *http.Request) bool {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return false
+	}
+
+	token := []byte(strings.TrimPrefix(header, "Bearer "))
+	ok := false
+	for _, known := range a.tokens {
+		if subtle.ConstantTimeCompare(token, known) == 1 {
+			ok = true
+		}
+	}
+
+	return ok
+}
+
+// listUsers serves GET /users
+func (a *API) listUsers(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != APIPath+"users" {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	offset, limit, err := parsePage(r)
+	if nil != err {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), apiTimeout)
+	defer cancel()
+
+	users, err := a.repo.FindAll(ctx)
+	if nil != err {
+		writeRepoError(w, err)
+		return
+	}
+
+	sortUsers(users)
+	start, end := pageBounds(len(users), offset, limit)
+	data := make([]apiUser, 0, end-start)
+	for _, user := range users[start:end] {
+		data = append(data, newAPIUser(user))
+	}
+
+	writeJSON(w, http.StatusOK, page{data, pagination{offset, limit, len(users)}})
+}
+
+// showUser serves GET /users/{id} and GET /users/{id}/statuses, which may be
+// narrowed down to a period with from and to
+func (a *API) showUser(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, APIPath+"users/"), "/")
+	if len(parts[0]) == 0 || len(parts) > 2 || (len(parts) == 2 && parts[1] != "statuses") {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), apiTimeout)
+	defer cancel()
+
+	user, err := a.repo.Find(ctx, parts[0])
+	if nil != err {
+		writeRepoError(w, err)
+		return
+	}
+
+	if len(parts) == 1 {
+		writeJSON(w, http.StatusOK, newAPIUser(user))
+		return
+	}
+
+	offset, limit, err := parsePage(r)
+	if nil != err {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	statuses := user.Statuses
+	if len(r.URL.Query().Get("from")) > 0 || len(r.URL.Query().Get("to")) > 0 {
+		from, to, err := parsePeriod(r)
+		if nil != err {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		statuses = overlappingStatuses(user, from, to)
+	}
+
+	start, end := pageBounds(len(statuses), offset, limit)
+	writeJSON(w, http.StatusOK, page{newAPIStatuses(statuses[start:end]), pagination{offset, limit, len(statuses)}})
+}
+
+// listOut serves GET /out?date= and GET /out?from=&to=, the users out then
+// with the statuses making them so
+func (a *API) listOut(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parsePeriod(r)
+	if nil != err {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	offset, limit, err := parsePage(r)
+	if nil != err {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), apiTimeout)
+	defer cancel()
+
+	users, err := a.repo.FindAllOutBetween(ctx, from, to)
+	if nil != err {
+		writeRepoError(w, err)
+		return
+	}
+
+	sortUsers(users)
+	start, end := pageBounds(len(users), offset, limit)
+	data := make([]apiAbsence, 0, end-start)
+	for _, user := range users[start:end] {
+		data = append(data, apiAbsence{newAPIUser(user), newAPIStatuses(overlappingStatuses(user, from, to))})
+	}
+
+	writeJSON(w, http.StatusOK, page{data, pagination{offset, limit, len(users)}})
+}
+
+// parsePeriod reads either a date, the whole day, or a from and a to, both
+// included. Dates are days in the time zone of the tz parameter, UTC by
+// default, while timestamps are taken as they are
+func parsePeriod(r *http.Request) (time.Time, time.Time, error) {
+	query := r.URL.Query()
+	loc := time.UTC
+	if tz := query.Get("tz"); len(tz) > 0 {
+		var err error
+		if loc, err = time.LoadLocation(tz); nil != err {
+			return time.Time{}, time.Time{}, errors.New("unknown time zone " + tz)
+		}
+	}
+
+	if date := query.Get("date"); len(date) > 0 {
+		from, isDate, err := parseAPITime(date, loc)
+		if nil != err {
+			return time.Time{}, time.Time{}, errInvalidPeriod
+		}
+
+		if isDate {
+			return from, htime.EndOfDay(from), nil
+		}
+
+		return from, from, nil
+	}
+
+	from, _, err := parseAPITime(query.Get("from"), loc)
+	if nil != err {
+		return time.Time{}, time.Time{}, errInvalidPeriod
+	}
+
+	to, isDate, err := parseAPITime(query.Get("to"), loc)
+	if nil != err || to.Before(from) {
+		return time.Time{}, time.Time{}, errInvalidPeriod
+	}
+
+	if isDate {
+		to = htime.EndOfDay(to)
+	}
+
+	return from, to, nil
+}
+
+// parseAPITime reads a day, such as 2018-02-20, or an RFC 3339 timestamp
+func parseAPITime(value string, loc *time.Location) (time.Time, bool, error) {
+	if t, err := time.ParseInLocation(apiDateLayout, value, loc); nil == err {
+		return t, true, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	return t, false, err
+}
+
+// parsePage reads the offset and the limit of a list
+func parsePage(r *http.Request) (int, int, error) {
+	query := r.URL.Query()
+	offset, limit := 0, defaultPageSize
+	if value := query.Get("offset"); len(value) > 0 {
+		n, err := strconv.Atoi(value)
+		if nil != err || n < 0 {
+			return 0, 0, errors.New("offset must be zero or more")
+		}
+
+		offset = n
+	}
+
+	if value := query.Get("limit"); len(value) > 0 {
+		n, err := strconv.Atoi(value)
+		if nil != err || n < 1 || n > maxPageSize {
+			return 0, 0, errors.New("limit must be between 1 and " + strconv.Itoa(maxPageSize))
+		}
+
+		limit = n
+	}
+
+	return offset, limit, nil
+}
+
+// pageBounds returns the part of a list of the given length on the page
+func pageBounds(length int, offset int, limit int) (int, int) {
+	if offset > length {
+		return length, length
+	}
+
+	if offset+limit > length {
+		return offset, length
+	}
+
+	return offset, offset + limit
+}
+
+// sortUsers orders the users by ID, not every storage keeps them in order
+// and the pages have to stay the same
+func sortUsers(users []*model.User) {
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].ID < users[j].ID
+	})
+}
+
+func overlappingStatuses(user *model.User, from time.Time, to time.Time) []*model.Status {
+	var statuses []*model.Status
+	for _, status := range user.Statuses {
+		if status.Overlaps(from, to) {
+			statuses = append(statuses, status)
+		}
+	}
+
+	return statuses
+}
+
+func newAPIUser(user *model.User) apiUser {
+	return apiUser{user.ID, user.Username, user.Location, user.IsAvailable(time.Now())}
+}
+
+func newAPIStatuses(statuses []*model.Status) []apiStatus {
+	data := make([]apiStatus, 0, len(statuses))
+	for _, status := range statuses {
+		s := apiStatus{ID: status.ID, Reason: status.Reason, Description: status.Description, From: status.From}
+		if !status.To.IsZero() {
+			to := status.To
+			s.To = &to
+		}
+
+		data = append(data, s)
+	}
+
+	return data
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); nil != err {
+		log.WithError(err).Warn("Could not write the response")
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, apiError{message})
+}
+
+// writeRepoError answers 404 for missing users and 503 when the storage
+// can't be reached, so clients know to try again
+func writeRepoError(w http.ResponseWriter, err error) {
+	switch {
+	case err == repo.ErrNotFound:
+		writeError(w, http.StatusNotFound, "user not found")
+	case repo.IsTransient(err):
+		writeError(w, http.StatusServiceUnavailable, "storage unavailable, try again later")
+	default:
+		log.WithError(err).Error("Could not answer the API request")
+		writeError(w, http.StatusInternalServerError, http.StatusText(http.StatusInternalServerError))
+	}
+}
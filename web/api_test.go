@@ -0,0 +1,419 @@
+package web
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/italolelis/hellowork/model"
+	"github.com/italolelis/hellowork/repo"
+)
+
+const testToken = "tok"
+
+// schema checks a decoded JSON value against a schema of openapi.go,
+// returning what doesn't match
+type schema func(v interface{}) error
+
+// object checks that every field of the schema is there, as openapi.go
+// requires them all, and that no other field is
+func object(fields map[string]schema) schema {
+	return func(v interface{}) error {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%v isn't an object", v)
+		}
+
+		for name, field := range fields {
+			value, ok := m[name]
+			if !ok {
+				return fmt.Errorf("%s is missing", name)
+			}
+
+			if err := field(value); nil != err {
+				return fmt.Errorf("%s: %v", name, err)
+			}
+		}
+
+		for name := range m {
+			if _, ok := fields[name]; !ok {
+				return fmt.Errorf("%s isn't in the schema", name)
+			}
+		}
+
+		return nil
+	}
+}
+
+func arrayOf(items schema) schema {
+	return func(v interface{}) error {
+		a, ok := v.([]interface{})
+		if !ok {
+			return fmt.Errorf("%v isn't an array", v)
+		}
+
+		for i, item := range a {
+			if err := items(item); nil != err {
+				return fmt.Errorf("[%d] %v", i, err)
+			}
+		}
+
+		return nil
+	}
+}
+
+func stringSchema(v interface{}) error {
+	if _, ok := v.(string); !ok {
+		return fmt.Errorf("%v isn't a string", v)
+	}
+
+	return nil
+}
+
+func booleanSchema(v interface{}) error {
+	if _, ok := v.(bool); !ok {
+		return fmt.Errorf("%v isn't a boolean", v)
+	}
+
+	return nil
+}
+
+func integerSchema(v interface{}) error {
+	if n, ok := v.(float64); !ok || n != float64(int(n)) {
+		return fmt.Errorf("%v isn't an integer", v)
+	}
+
+	return nil
+}
+
+func dateTimeSchema(v interface{}) error {
+	s, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("%v isn't a date-time", v)
+	}
+
+	_, err := time.Parse(time.RFC3339, s)
+	return err
+}
+
+func nullable(s schema) schema {
+	return func(v interface{}) error {
+		if nil == v {
+			return nil
+		}
+
+		return s(v)
+	}
+}
+
+func enum(values ...model.Reason) schema {
+	return func(v interface{}) error {
+		for _, value := range values {
+			if v == string(value) {
+				return nil
+			}
+		}
+
+		return fmt.Errorf("%v isn't one of %v", v, values)
+	}
+}
+
+var (
+	reasons = []model.Reason{model.Vacation, model.Sick, model.WorkTrip, model.Remote, model.OutOfOffice}
+
+	userSchema = object(map[string]schema{
+		"id":        stringSchema,
+		"username":  stringSchema,
+		"location":  stringSchema,
+		"available": booleanSchema,
+	})
+	statusSchema = object(map[string]schema{
+		"id":          stringSchema,
+		"reason":      enum(reasons...),
+		"description": stringSchema,
+		"from":        dateTimeSchema,
+		"to":          nullable(dateTimeSchema),
+	})
+	absenceSchema = object(map[string]schema{
+		"user":     userSchema,
+		"statuses": arrayOf(statusSchema),
+	})
+	paginationSchema = object(map[string]schema{
+		"offset": integerSchema,
+		"limit":  integerSchema,
+		"total":  integerSchema,
+	})
+	errorSchema = object(map[string]schema{
+		"error": stringSchema,
+	})
+)
+
+func pageSchema(items schema) schema {
+	return object(map[string]schema{
+		"data":       arrayOf(items),
+		"pagination": paginationSchema,
+	})
+}
+
+// failingRepository can't reach its storage
+type failingRepository struct {
+	repo.Repository
+}
+
+func (failingRepository) FindAll(ctx context.Context) ([]*model.User, error) {
+	return nil, &repo.Error{Err: errors.New("no reachable servers"), Transient: true}
+}
+
+func newTestAPI(t *testing.T) *API {
+	r := repo.NewInMemory()
+	for _, id := range []model.UserID{"U3", "U1", "U2"} {
+		user := model.NewUser(id)
+		user.Username = strings.ToLower(string(id))
+		user.Location = "Europe/Berlin"
+		user.Profile = &model.ProfileSync{Token: "xoxp-secret"}
+		if id != "U3" {
+			user.AddStatus(model.NewStatus("Mallorca", time.Date(2026, 11, 2, 0, 0, 0, 0, time.UTC), time.Date(2026, 11, 3, 23, 0, 0, 0, time.UTC), model.Vacation), id)
+			user.AddStatus(model.NewStatus("", time.Date(2026, 12, 2, 0, 0, 0, 0, time.UTC), time.Time{}, model.Sick), id)
+		}
+
+		if err := r.Add(context.Background(), user); nil != err {
+			t.Fatal(err)
+		}
+	}
+
+	return NewAPI(r, []string{testToken, " "})
+}
+
+// serve answers the request, with the test token unless another one is
+// given
+func serve(a http.Handler, method string, path string, token ...string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if len(token) == 0 {
+		token = []string{testToken}
+	}
+
+	if len(token[0]) > 0 {
+		req.Header.Set("Authorization", "Bearer "+token[0])
+	}
+
+	rec := httptest.NewRecorder()
+	a.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAPIContract(t *testing.T) {
+	a := newTestAPI(t)
+	tests := []struct {
+		path   string
+		status int
+		schema schema
+	}{
+		{"/api/users", http.StatusOK, pageSchema(userSchema)},
+		{"/api/users?limit=2&offset=1", http.StatusOK, pageSchema(userSchema)},
+		{"/api/users?offset=10", http.StatusOK, pageSchema(userSchema)},
+		{"/api/users?offset=0&limit=200", http.StatusOK, pageSchema(userSchema)},
+		{"/api/users?limit=0", http.StatusBadRequest, errorSchema},
+		{"/api/users?limit=201", http.StatusBadRequest, errorSchema},
+		{"/api/users?offset=-1", http.StatusBadRequest, errorSchema},
+		{"/api/users/U1", http.StatusOK, userSchema},
+		{"/api/users/U9", http.StatusNotFound, errorSchema},
+		{"/api/users/U1/statuses", http.StatusOK, pageSchema(statusSchema)},
+		{"/api/users/U1/statuses?from=2026-11-03&to=2026-11-03", http.StatusOK, pageSchema(statusSchema)},
+		{"/api/users/U1/statuses?from=2026-11-03", http.StatusBadRequest, errorSchema},
+		{"/api/users/U9/statuses", http.StatusNotFound, errorSchema},
+		{"/api/users/U1/history", http.StatusNotFound, errorSchema},
+		{"/api/out?date=2026-11-03", http.StatusOK, pageSchema(absenceSchema)},
+		{"/api/out?date=2026-11-03T12:00:00Z", http.StatusOK, pageSchema(absenceSchema)},
+		{"/api/out?from=2026-11-04T00:00:00Z&to=2026-11-30&tz=Europe/Berlin", http.StatusOK, pageSchema(absenceSchema)},
+		{"/api/out?from=2026-11-04&to=2026-11-01", http.StatusBadRequest, errorSchema},
+		{"/api/out", http.StatusBadRequest, errorSchema},
+		{"/api/out?date=tomorrow", http.StatusBadRequest, errorSchema},
+		{"/api/out?date=2026-11-03&tz=Nope/Zone", http.StatusBadRequest, errorSchema},
+		{"/nothing", http.StatusNotFound, nil},
+		{"/users", http.StatusNotFound, nil},
+		{"/openapi.yaml", http.StatusNotFound, nil},
+	}
+
+	for _, tt := range tests {
+		rec := serve(a, http.MethodGet, tt.path)
+		if rec.Code != tt.status {
+			t.Errorf("GET %s answered %d, want %d: %s", tt.path, rec.Code, tt.status, rec.Body)
+			continue
+		}
+
+		if nil == tt.schema {
+			continue
+		}
+
+		if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+			t.Errorf("GET %s is %s", tt.path, ct)
+		}
+
+		var body interface{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); nil != err {
+			t.Errorf("GET %s: %v", tt.path, err)
+			continue
+		}
+
+		if err := tt.schema(body); nil != err {
+			t.Errorf("GET %s doesn't match the schema: %v", tt.path, err)
+		}
+
+		if strings.Contains(rec.Body.String(), "xoxp-secret") {
+			t.Errorf("GET %s shows the profile token", tt.path)
+		}
+	}
+}
+
+func TestAPIAuthentication(t *testing.T) {
+	a := newTestAPI(t)
+	for _, token := range []string{"", "wrong", " ", testToken + "x"} {
+		rec := serve(a, http.MethodGet, "/api/users", token)
+		if rec.Code != http.StatusUnauthorized || !strings.HasPrefix(rec.Header().Get("WWW-Authenticate"), "Bearer") {
+			t.Errorf("the token %q got %d", token, rec.Code)
+		}
+	}
+
+	rec := serve(a, http.MethodGet, OpenAPIPath, "")
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "application/yaml") {
+		t.Errorf("%s without a token got %d", OpenAPIPath, rec.Code)
+	}
+
+	rec = serve(a, http.MethodPost, "/api/users")
+	if rec.Code != http.StatusMethodNotAllowed || rec.Header().Get("Allow") != "GET, HEAD" {
+		t.Errorf("POST /users got %d allowing %q", rec.Code, rec.Header().Get("Allow"))
+	}
+}
+
+func TestAPIAnswers(t *testing.T) {
+	a := newTestAPI(t)
+	decode := func(path string) (ids []string, total int) {
+		var body struct {
+			Data []struct {
+				ID   string `json:"id"`
+				User struct {
+					ID string `json:"id"`
+				} `json:"user"`
+				To *time.Time `json:"to"`
+			} `json:"data"`
+			Pagination pagination `json:"pagination"`
+		}
+
+		if err := json.Unmarshal(serve(a, http.MethodGet, path).Body.Bytes(), &body); nil != err {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+
+		for _, item := range body.Data {
+			ids = append(ids, item.ID+item.User.ID)
+		}
+
+		return ids, body.Pagination.Total
+	}
+
+	if ids, total := decode("/api/users?limit=2&offset=1"); strings.Join(ids, ",") != "U2,U3" || total != 3 {
+		t.Errorf("the second page of users is %v of %d", ids, total)
+	}
+
+	if ids, total := decode("/api/out?date=2026-11-03"); strings.Join(ids, ",") != "U1,U2" || total != 2 {
+		t.Errorf("out on 2026-11-03 are %v of %d", ids, total)
+	}
+
+	if ids, _ := decode("/api/out?date=2026-11-04"); len(ids) != 0 {
+		t.Errorf("out on 2026-11-04 are %v", ids)
+	}
+
+	if ids, _ := decode("/api/out?date=2026-11-04&tz=Asia/Tokyo"); strings.Join(ids, ",") != "U1,U2" {
+		t.Errorf("out on 2026-11-04 in Tokyo are %v, the vacation ends then", ids)
+	}
+
+	if ids, total := decode("/api/users/U1/statuses?from=2026-11-03&to=2026-11-03"); len(ids) != 1 || total != 1 {
+		t.Errorf("U1 has %v statuses on 2026-11-03", ids)
+	}
+}
+
+func TestParsePage(t *testing.T) {
+	cases := []struct {
+		query  string
+		offset int
+		limit  int
+		err    string
+	}{
+		{"", 0, defaultPageSize, ""},
+		{"offset=0&limit=1", 0, 1, ""},
+		{"offset=3&limit=200", 3, 200, ""},
+		{"offset=-1", 0, 0, "offset must be zero or more"},
+		{"offset=one", 0, 0, "offset must be zero or more"},
+		{"limit=0", 0, 0, "limit must be between 1 and 200"},
+		{"limit=201", 0, 0, "limit must be between 1 and 200"},
+		{"limit=ten", 0, 0, "limit must be between 1 and 200"},
+	}
+
+	for _, c := range cases {
+		offset, limit, err := parsePage(httptest.NewRequest(http.MethodGet, "/api/users?"+c.query, nil))
+		message := ""
+		if nil != err {
+			message = err.Error()
+		}
+
+		if offset != c.offset || limit != c.limit || message != c.err {
+			t.Errorf("%q: got %d, %d, %q", c.query, offset, limit, message)
+		}
+	}
+}
+
+func TestAPIUnavailableStorage(t *testing.T) {
+	rec := serve(NewAPI(failingRepository{}, []string{testToken}), http.MethodGet, "/api/users")
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("GET /users without a storage got %d", rec.Code)
+	}
+
+	var body interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); nil != err || nil != errorSchema(body) {
+		t.Errorf("GET /users without a storage answered %s", rec.Body)
+	}
+}
+
+// TestOpenAPISpec keeps openapi.go in line with the schemas above
+func TestOpenAPISpec(t *testing.T) {
+	for _, required := range []string{
+		"required: [id, username, location, available]",
+		"required: [id, reason, description, from, to]",
+		"required: [user, statuses]",
+		"required: [offset, limit, total]",
+		"required: [error]",
+		"required: [data, pagination]",
+		fmt.Sprintf("maximum: %d", maxPageSize),
+		fmt.Sprintf("default: %d", defaultPageSize),
+	} {
+		if !strings.Contains(openAPISpec, required) {
+			t.Errorf("the spec lacks %q", required)
+		}
+	}
+
+	var names []string
+	for _, reason := range reasons {
+		names = append(names, string(reason))
+	}
+
+	spec := strings.Split(openAPISpec, "enum: [")[1]
+	spec = spec[:strings.Index(spec, "]")]
+	inSpec := strings.Split(spec, ", ")
+	sort.Strings(names)
+	sort.Strings(inSpec)
+	if strings.Join(names, ",") != strings.Join(inSpec, ",") {
+		t.Errorf("the spec lists the reasons %v, the model has %v", inSpec, names)
+	}
+
+	for _, path := range []string{"/users:", "/users/{id}:", "/users/{id}/statuses:", "/out:"} {
+		if !strings.Contains(openAPISpec, "\n  "+path+"\n") {
+			t.Errorf("the spec lacks %s", path)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package web
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/italolelis/hellowork/i18n"
+	"github.com/italolelis/hellowork/model"
+	"github.com/italolelis/hellowork/repo"
+	"github.com/nlopes/slack"
+)
+
+const (
+	// FeedsPath is where the feeds are served
+	FeedsPath = "/feeds/"
+	// AllFeed is the feed of everybody
+	AllFeed = "all"
+
+	// feedPast and feedFuture bound the statuses in a feed, calendars don't
+	// need the whole history
+	feedPast    = 90 * 24 * time.Hour
+	feedFuture  = 2 * 365 * 24 * time.Hour
+	feedTimeout = 10 * time.Second
+)
+
+var errUnknownFeed = errors.New("unknown feed")
+
+// UserFeed is the feed of a single user
+func UserFeed(id string) string {
+	return "users/" + id
+}
+
+// TeamFeed is the feed of the members of a slack user group
+func TeamFeed(id string) string {
+	return "teams/" + id
+}
+
+// Teams resolves the members of a slack user group
+type Teams interface {
+	Members(team string) ([]string, error)
+}
+
+// SlackTeams asks the web API for the members of user groups
+type SlackTeams struct {
+	client *slack.Client
+}
+
+func NewSlackTeams(client *slack.Client) *SlackTeams {
+	return &SlackTeams{client}
+}
+
+func (t *SlackTeams) Members(team string) ([]string, error) {
+	return t.client.GetUserGroupMembers(team)
+}
+
+// Feeds serves the statuses as iCalendar feeds anybody can subscribe to in
+// their calendar app. Calendar apps can't log in, so every feed has its own
+// token derived from the secret, which is only given out by the bot
+type Feeds struct {
+	repo      repo.Repository
+	teams     Teams
+	catalogue *i18n.Catalogue
+	secret    []byte
+	publicURL string
+	now       func() time.Time
+}
+
+// NewFeeds creates the feeds, publicURL being where the server is reachable
+// from the outside, such as "https://hellowork.example.com"
+func NewFeeds(repo repo.Repository, teams Teams, catalogue *i18n.Catalogue, secret string, publicURL string) *Feeds {
+	return &Feeds{repo, teams, catalogue, []byte(secret), strings.TrimSuffix(publicURL, "/"), time.Now}
+}
+
+// Token returns the token of the feed, which stays the same as long as the
+// secret does
+func (f *Feeds) Token(feed string) string {
+	mac := hmac.New(sha256.New, f.secret)
+	mac.Write([]byte(feed))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// URL returns the address to subscribe to the feed, token included
+func (f *Feeds) URL(feed string) string {
+	return f.publicURL + FeedsPath + feed + ".ics?token=" + f.Token(feed)
+}
+
+func (f *Feeds) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	feed := strings.TrimPrefix(r.URL.Path, FeedsPath)
+	token := r.URL.Query().Get("token")
+	// a wrong token looks like a missing feed, so feeds can't be guessed
+	if !strings.HasSuffix(feed, ".ics") || !hmac.Equal([]byte(token), []byte(f.Token(strings.TrimSuffix(feed, ".ics")))) {
+		http.NotFound(w, r)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), feedTimeout)
+	defer cancel()
+
+	users, err := f.users(ctx, strings.TrimSuffix(feed, ".ics"))
+	switch {
+	case err == errUnknownFeed:
+		http.NotFound(w, r)
+		return
+	case nil != err:
+		log.WithError(err).WithField("feed", feed).Error("Could not load the feed")
+		status := http.StatusInternalServerError
+		if repo.IsTransient(err) {
+			status = http.StatusServiceUnavailable
+		}
+
+		http.Error(w, http.StatusText(status), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	if err := f.write(w, users); nil != err {
+		log.WithError(err).WithField("feed", feed).Warn("Could not write the feed")
+	}
+}
+
+// users loads the users of the feed with their statuses
+func (f *Feeds) users(ctx context.Context, feed string) ([]*model.User, error) {
+	now := f.now()
+	if feed == AllFeed {
+		return f.repo.FindAllOutBetween(ctx, now.Add(-feedPast), now.Add(feedFuture))
+	}
+
+	var ids []string
+	switch parts := strings.SplitN(feed, "/", 2); {
+	case len(parts) != 2 || len(parts[1]) == 0:
+		return nil, errUnknownFeed
+	case parts[0] == "users":
+		ids = []string{parts[1]}
+	case parts[0] == "teams":
+		members, err := f.teams.Members(parts[1])
+		if nil != err {
+			return nil, err
+		}
+
+		ids = members
+	default:
+		return nil, errUnknownFeed
+	}
+
+	// users who never had a status get an empty calendar, they may add one
+	// after subscribing
+	var users []*model.User
+	for _, id := range ids {
+		user, err := f.repo.Find(ctx, id)
+		switch {
+		case err == repo.ErrNotFound:
+			continue
+		case nil != err:
+			return nil, err
+		}
+
+		users = append(users, user)
+	}
+
+	return users, nil
+}
+
+// write renders the statuses of the users with an end, open ended ones
+// can't be drawn in a calendar
+func (f *Feeds) write(w http.ResponseWriter, users []*model.User) error {
+	now := f.now()
+	from, to := now.Add(-feedPast), now.Add(feedFuture)
+
+	ics := &icsWriter{w: w}
+	ics.line("BEGIN", "VCALENDAR")
+	ics.line("VERSION", "2.0")
+	ics.line("PRODID", "-//hellowork//absences//EN")
+	ics.line("CALSCALE", "GREGORIAN")
+	ics.line("METHOD", "PUBLISH")
+	ics.text("X-WR-CALNAME", f.catalogue.T("feed_name"))
+	for _, user := range users {
+		for _, status := range user.Statuses {
+			if status.To.IsZero() || !status.Overlaps(from, to) {
+				continue
+			}
+
+			ics.event(user, status, f.catalogue.T("feed_summary", feedName(user), status.Reason), now)
+		}
+	}
+
+	ics.line("END", "VCALENDAR")
+	return ics.err
+}
+
+// feedName is the username, calendars can't render mentions
+func feedName(user *model.User) string {
+	if len(user.Username) > 0 {
+		return user.Username
+	}
+
+	return string(user.ID)
+}
@@ -0,0 +1,250 @@
+package web
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/italolelis/hellowork/i18n"
+	"github.com/italolelis/hellowork/model"
+	"github.com/italolelis/hellowork/repo"
+)
+
+// fakeTeams maps the user groups to their members
+type fakeTeams map[string][]string
+
+func (t fakeTeams) Members(team string) ([]string, error) {
+	return t[team], nil
+}
+
+// newTestFeeds serves the feeds of wally in Berlin, who has a couple of
+// statuses around March 2018, and of U2, who is remote for a day
+func newTestFeeds(t *testing.T) (*Feeds, repo.Repository) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	r := repo.NewInMemory()
+
+	wally := model.NewUser("U1")
+	wally.Username = "wally"
+	wally.Location = "Europe/Berlin"
+	for _, status := range []*model.Status{
+		model.NewStatus(
+			"Skiing; then Berlin, maybe\r\nX-INJECTED:1\rand a line that goes on and on so that it has to be folded, über",
+			time.Date(2018, time.March, 5, 0, 0, 0, 0, berlin), time.Date(2018, time.March, 6, 23, 59, 59, 999999999, berlin), model.Vacation,
+		),
+		model.NewStatus("", time.Date(2018, time.March, 8, 13, 0, 0, 0, berlin), time.Date(2018, time.March, 8, 23, 59, 59, 999999999, berlin), model.Sick),
+		// open ended statuses and the ones out of the window are left out
+		model.NewStatus("", time.Date(2018, time.March, 12, 0, 0, 0, 0, berlin), time.Time{}, model.Sick),
+		model.NewStatus("", time.Date(2017, time.June, 1, 0, 0, 0, 0, berlin), time.Date(2017, time.June, 2, 23, 59, 59, 999999999, berlin), model.Vacation),
+	} {
+		status.ID = model.StatusID(fmt.Sprintf("S%d", len(wally.Statuses)+1))
+		wally.AddStatus(status, "U1")
+	}
+
+	other := model.NewUser("U2")
+	remote := model.NewStatus("", time.Date(2018, time.March, 10, 0, 0, 0, 0, time.UTC), time.Date(2018, time.March, 10, 23, 59, 59, 999999999, time.UTC), model.Remote)
+	remote.ID = "S5"
+	other.AddStatus(remote, "U2")
+
+	for _, user := range []*model.User{wally, other} {
+		if err := r.Add(ctx, user); nil != err {
+			t.Fatal(err)
+		}
+	}
+
+	f := NewFeeds(r, fakeTeams{"T1": {"U1", "U2", "U9"}}, i18n.Get(i18n.English), "s3cret", "https://hellowork.example.com/")
+	f.now = func() time.Time { return time.Date(2018, time.March, 1, 10, 0, 0, 0, time.UTC) }
+
+	return f, r
+}
+
+// getFeed requests the feed with its own token
+func getFeed(f *Feeds, feed string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	f.ServeHTTP(w, httptest.NewRequest(http.MethodGet, strings.TrimPrefix(f.URL(feed), "https://hellowork.example.com"), nil))
+
+	return w
+}
+
+func TestFeedGolden(t *testing.T) {
+	f, _ := newTestFeeds(t)
+	want := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//hellowork//absences//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"X-WR-CALNAME:Who is out",
+		"BEGIN:VEVENT",
+		"UID:U1-S1@hellowork",
+		"DTSTAMP:20180301T100000Z",
+		"DTSTART;VALUE=DATE:20180305",
+		"DTEND;VALUE=DATE:20180307",
+		"SUMMARY:wally: vacation",
+		`DESCRIPTION:Skiing\; then Berlin\, maybe\nX-INJECTED:1\nand a line that goe`,
+		` s on and on so that it has to be folded\, über`,
+		"CATEGORIES:vacation",
+		"TRANSP:TRANSPARENT",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:U1-S2@hellowork",
+		"DTSTAMP:20180301T100000Z",
+		"DTSTART:20180308T120000Z",
+		"DTEND:20180308T230000Z",
+		"SUMMARY:wally: sick",
+		"CATEGORIES:sick",
+		"TRANSP:TRANSPARENT",
+		"END:VEVENT",
+		"BEGIN:VEVENT",
+		"UID:U2-S5@hellowork",
+		"DTSTAMP:20180301T100000Z",
+		"DTSTART;VALUE=DATE:20180310",
+		"DTEND;VALUE=DATE:20180311",
+		"SUMMARY:U2: working remote",
+		"CATEGORIES:working remote",
+		"TRANSP:TRANSPARENT",
+		"END:VEVENT",
+		"END:VCALENDAR",
+		"",
+	}, "\r\n")
+
+	w := getFeed(f, TeamFeed("T1"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("got status %d", w.Code)
+	}
+
+	if ct := w.Header().Get("Content-Type"); ct != "text/calendar; charset=utf-8" {
+		t.Errorf("got content type %q", ct)
+	}
+
+	if got := w.Body.String(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestFeedUIDsAreStable(t *testing.T) {
+	f, r := newTestFeeds(t)
+	before := getFeed(f, UserFeed("U1")).Body.String()
+
+	err := repo.Update(context.Background(), r, "U1", func(user *model.User) error {
+		moved := *user.FindStatus("S1")
+		moved.From = moved.From.AddDate(0, 0, 1)
+		moved.To = moved.To.AddDate(0, 0, 2)
+
+		return user.EditStatus(&moved, "U1")
+	})
+	if nil != err {
+		t.Fatal(err)
+	}
+
+	after := getFeed(f, UserFeed("U1")).Body.String()
+	if before == after {
+		t.Fatal("the feed didn't change")
+	}
+
+	for _, uid := range []string{"UID:U1-S1@hellowork", "UID:U1-S2@hellowork"} {
+		if !strings.Contains(after, uid+"\r\n") {
+			t.Errorf("%s is gone after moving the status", uid)
+		}
+	}
+
+	if !strings.Contains(after, "DTSTART;VALUE=DATE:20180306\r\nDTEND;VALUE=DATE:20180309\r\n") {
+		t.Errorf("the moved status isn't in the feed:\n%s", after)
+	}
+}
+
+func TestFeedTokens(t *testing.T) {
+	f, _ := newTestFeeds(t)
+	other := NewFeeds(nil, nil, nil, "another secret", "")
+
+	cases := []struct {
+		name   string
+		method string
+		target string
+		code   int
+	}{
+		{"everybody", http.MethodGet, "/feeds/all.ics?token=" + f.Token(AllFeed), http.StatusOK},
+		{"a user", http.MethodGet, "/feeds/users/U1.ics?token=" + f.Token(UserFeed("U1")), http.StatusOK},
+		{"a user without statuses", http.MethodGet, "/feeds/users/U9.ics?token=" + f.Token(UserFeed("U9")), http.StatusOK},
+		{"head", http.MethodHead, "/feeds/all.ics?token=" + f.Token(AllFeed), http.StatusOK},
+		{"no token", http.MethodGet, "/feeds/all.ics", http.StatusNotFound},
+		{"wrong token", http.MethodGet, "/feeds/all.ics?token=0123abcd", http.StatusNotFound},
+		{"token of another feed", http.MethodGet, "/feeds/users/U1.ics?token=" + f.Token(UserFeed("U2")), http.StatusNotFound},
+		{"token of another secret", http.MethodGet, "/feeds/all.ics?token=" + other.Token(AllFeed), http.StatusNotFound},
+		{"token in upper case", http.MethodGet, "/feeds/all.ics?token=" + strings.ToUpper(f.Token(AllFeed)), http.StatusNotFound},
+		{"without the extension", http.MethodGet, "/feeds/all?token=" + f.Token(AllFeed), http.StatusNotFound},
+		{"unknown kind of feed", http.MethodGet, "/feeds/rooms/R1.ics?token=" + f.Token("rooms/R1"), http.StatusNotFound},
+		{"post", http.MethodPost, "/feeds/all.ics?token=" + f.Token(AllFeed), http.StatusMethodNotAllowed},
+	}
+
+	for _, c := range cases {
+		w := httptest.NewRecorder()
+		f.ServeHTTP(w, httptest.NewRequest(c.method, c.target, nil))
+		if w.Code != c.code {
+			t.Errorf("%s: got status %d, want %d", c.name, w.Code, c.code)
+		}
+
+		if w.Code != http.StatusOK && strings.Contains(w.Body.String(), "BEGIN:VCALENDAR") {
+			t.Errorf("%s: the feed was served anyway", c.name)
+		}
+	}
+}
+
+func TestICSWriterFoldsLines(t *testing.T) {
+	var b bytes.Buffer
+	w := &icsWriter{w: &b}
+	w.text("DESCRIPTION", strings.Repeat("a", 60)+strings.Repeat("ü", 40))
+	w.line("SUMMARY", strings.Repeat("b", 67))
+
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\r\n"), "\r\n")
+	for i, line := range lines {
+		if len(line) > icsLineLength {
+			t.Errorf("line %d has %d octets", i, len(line))
+		}
+
+		if !utf8.ValidString(line) {
+			t.Errorf("line %d cuts a character in half: %q", i, line)
+		}
+
+		if i > 0 && i < len(lines)-1 && !strings.HasPrefix(line, " ") {
+			t.Errorf("continuation %d doesn't start with a space: %q", i, line)
+		}
+	}
+
+	// the summary fits exactly
+	if last := lines[len(lines)-1]; last != "SUMMARY:"+strings.Repeat("b", 67) {
+		t.Errorf("got %q", last)
+	}
+
+	unfolded := strings.Replace(b.String(), "\r\n ", "", -1)
+	if !strings.HasPrefix(unfolded, "DESCRIPTION:"+strings.Repeat("a", 60)+strings.Repeat("ü", 40)+"\r\n") {
+		t.Errorf("unfolding doesn't give the text back: %q", unfolded)
+	}
+}
+
+func TestICSEscaper(t *testing.T) {
+	cases := map[string]string{
+		`back\slash`:          `back\\slash`,
+		"a;b,c":               `a\;b\,c`,
+		"line\nbreak":         `line\nbreak`,
+		"windows\r\nbreak":    `windows\nbreak`,
+		"bare\rCR":            `bare\nCR`,
+		"x\r\nUID:evil\r\n\r": `x\nUID:evil\n\n`,
+	}
+
+	for in, want := range cases {
+		if got := icsEscaper.Replace(in); got != want {
+			t.Errorf("escaping %q: got %q, want %q", in, got, want)
+		}
+	}
+}
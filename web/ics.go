@@ -0,0 +1,94 @@
+package web
+
+import (
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/italolelis/hellowork/model"
+	htime "github.com/italolelis/hellowork/time"
+)
+
+const (
+	icsDateLayout     = "20060102"
+	icsDateTimeLayout = "20060102T150405Z"
+	// icsLineLength is the most octets a line may have before it's folded
+	icsLineLength = 75
+)
+
+// icsEscaper escapes text values. Every kind of line break becomes an
+// escaped newline, a bare CR would otherwise end the line and let the text
+// add properties of its own
+var icsEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\r", `\n`, "\n", `\n`)
+
+// icsWriter writes the content lines of an iCalendar object as RFC 5545
+// wants them: ended by CRLF and folded when too long. It keeps the first
+// error and ignores everything after it
+type icsWriter struct {
+	w   io.Writer
+	err error
+}
+
+// line writes a property, its value already escaped when it's text
+func (w *icsWriter) line(name string, value string) {
+	if nil != w.err {
+		return
+	}
+
+	line := name + ":" + value
+	var folded []string
+	for len(line) > icsLineLength {
+		// never cut a UTF-8 sequence in half
+		cut := icsLineLength
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+
+		folded = append(folded, line[:cut])
+		// the leading space of a continuation counts towards its length
+		line = " " + line[cut:]
+	}
+
+	folded = append(folded, line)
+	_, w.err = io.WriteString(w.w, strings.Join(folded, "\r\n")+"\r\n")
+}
+
+// text writes a property whose value is free text
+func (w *icsWriter) text(name string, value string) {
+	w.line(name, icsEscaper.Replace(value))
+}
+
+// event writes the status as an event. Statuses covering whole days in the
+// user's time zone are all-day events, half days are timed ones
+func (w *icsWriter) event(user *model.User, status *model.Status, summary string, stamp time.Time) {
+	loc := user.TimeLocation()
+	from, to := status.From.In(loc), status.To.In(loc)
+
+	w.line("BEGIN", "VEVENT")
+	w.line("UID", FeedUID(user.ID, status.ID))
+	w.line("DTSTAMP", stamp.UTC().Format(icsDateTimeLayout))
+	if from.Equal(htime.StartOfDay(from)) && to.Equal(htime.EndOfDay(to)) {
+		// the end of an all-day event is the day after the last one
+		w.line("DTSTART;VALUE=DATE", from.Format(icsDateLayout))
+		w.line("DTEND;VALUE=DATE", to.AddDate(0, 0, 1).Format(icsDateLayout))
+	} else {
+		w.line("DTSTART", from.UTC().Format(icsDateTimeLayout))
+		w.line("DTEND", to.Add(time.Nanosecond).UTC().Format(icsDateTimeLayout))
+	}
+
+	w.text("SUMMARY", summary)
+	if len(status.Description) > 0 {
+		w.text("DESCRIPTION", status.Description)
+	}
+
+	w.text("CATEGORIES", string(status.Reason))
+	w.line("TRANSP", "TRANSPARENT")
+	w.line("END", "VEVENT")
+}
+
+// FeedUID is the UID of the event of a status, which stays the same however
+// often the status changes
+func FeedUID(user model.UserID, status model.StatusID) string {
+	return string(user) + "-" + string(status) + "@hellowork"
+}
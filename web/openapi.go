@@ -0,0 +1,262 @@
+package web
+
+// openAPISpec describes the API, served at OpenAPIPath. Keep it in line with
+// api.go
+const openAPISpec = `openapi: 3.0.0
+info:
+  title: hellowork
+  description: Who is out of the office, and why
+  version: 1.0.0
+servers:
+  - url: /api
+security:
+  - token: []
+paths:
+  /users:
+    get:
+      summary: Lists the users, ordered by ID
+      parameters:
+        - $ref: '#/components/parameters/offset'
+        - $ref: '#/components/parameters/limit'
+      responses:
+        '200':
+          description: A page of users
+          content:
+            application/json:
+              schema:
+                type: object
+                required: [data, pagination]
+                properties:
+                  data:
+                    type: array
+                    items:
+                      $ref: '#/components/schemas/User'
+                  pagination:
+                    $ref: '#/components/schemas/Pagination'
+        '400':
+          $ref: '#/components/responses/BadRequest'
+        '401':
+          $ref: '#/components/responses/Unauthorized'
+        '503':
+          $ref: '#/components/responses/Unavailable'
+  /users/{id}:
+    get:
+      summary: Shows a user
+      parameters:
+        - $ref: '#/components/parameters/id'
+      responses:
+        '200':
+          description: The user
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/User'
+        '401':
+          $ref: '#/components/responses/Unauthorized'
+        '404':
+          $ref: '#/components/responses/NotFound'
+        '503':
+          $ref: '#/components/responses/Unavailable'
+  /users/{id}/statuses:
+    get:
+      summary: Lists the statuses of a user, all of them or the ones overlapping from and to
+      parameters:
+        - $ref: '#/components/parameters/id'
+        - $ref: '#/components/parameters/from'
+        - $ref: '#/components/parameters/to'
+        - $ref: '#/components/parameters/tz'
+        - $ref: '#/components/parameters/offset'
+        - $ref: '#/components/parameters/limit'
+      responses:
+        '200':
+          description: A page of statuses
+          content:
+            application/json:
+              schema:
+                type: object
+                required: [data, pagination]
+                properties:
+                  data:
+                    type: array
+                    items:
+                      $ref: '#/components/schemas/Status'
+                  pagination:
+                    $ref: '#/components/schemas/Pagination'
+        '400':
+          $ref: '#/components/responses/BadRequest'
+        '401':
+          $ref: '#/components/responses/Unauthorized'
+        '404':
+          $ref: '#/components/responses/NotFound'
+        '503':
+          $ref: '#/components/responses/Unavailable'
+  /out:
+    get:
+      summary: Lists who is out on a date, or between from and to, ordered by user ID
+      description: Give either date, or both from and to
+      parameters:
+        - name: date
+          in: query
+          description: A day, such as 2018-02-20, or an RFC 3339 timestamp
+          schema:
+            type: string
+        - $ref: '#/components/parameters/from'
+        - $ref: '#/components/parameters/to'
+        - $ref: '#/components/parameters/tz'
+        - $ref: '#/components/parameters/offset'
+        - $ref: '#/components/parameters/limit'
+      responses:
+        '200':
+          description: A page of users out with the statuses making them so
+          content:
+            application/json:
+              schema:
+                type: object
+                required: [data, pagination]
+                properties:
+                  data:
+                    type: array
+                    items:
+                      $ref: '#/components/schemas/Absence'
+                  pagination:
+                    $ref: '#/components/schemas/Pagination'
+        '400':
+          $ref: '#/components/responses/BadRequest'
+        '401':
+          $ref: '#/components/responses/Unauthorized'
+        '503':
+          $ref: '#/components/responses/Unavailable'
+components:
+  securitySchemes:
+    token:
+      type: http
+      scheme: bearer
+      description: One of the API_TOKENS of the server
+  parameters:
+    id:
+      name: id
+      in: path
+      required: true
+      description: The slack ID of the user, such as U023BECGF
+      schema:
+        type: string
+    from:
+      name: from
+      in: query
+      description: A day, such as 2018-02-20, or an RFC 3339 timestamp
+      schema:
+        type: string
+    to:
+      name: to
+      in: query
+      description: A day, included, or an RFC 3339 timestamp
+      schema:
+        type: string
+    tz:
+      name: tz
+      in: query
+      description: The IANA time zone of the days, UTC by default
+      schema:
+        type: string
+        example: Europe/Berlin
+    offset:
+      name: offset
+      in: query
+      schema:
+        type: integer
+        minimum: 0
+        default: 0
+    limit:
+      name: limit
+      in: query
+      schema:
+        type: integer
+        minimum: 1
+        maximum: 200
+        default: 50
+  schemas:
+    User:
+      type: object
+      required: [id, username, location, available]
+      properties:
+        id:
+          type: string
+        username:
+          type: string
+        location:
+          type: string
+          description: The IANA time zone of the user
+        available:
+          type: boolean
+          description: Whether no status covers the time of the request
+    Status:
+      type: object
+      required: [id, reason, description, from, to]
+      properties:
+        id:
+          type: string
+        reason:
+          type: string
+          enum: [vacation, sick, work trip, working remote, out of office]
+        description:
+          type: string
+        from:
+          type: string
+          format: date-time
+        to:
+          type: string
+          format: date-time
+          nullable: true
+          description: Null while hellowork waits to hear when the user is back
+    Absence:
+      type: object
+      required: [user, statuses]
+      properties:
+        user:
+          $ref: '#/components/schemas/User'
+        statuses:
+          type: array
+          items:
+            $ref: '#/components/schemas/Status'
+    Pagination:
+      type: object
+      required: [offset, limit, total]
+      properties:
+        offset:
+          type: integer
+        limit:
+          type: integer
+        total:
+          type: integer
+    Error:
+      type: object
+      required: [error]
+      properties:
+        error:
+          type: string
+  responses:
+    BadRequest:
+      description: Invalid parameters
+      content:
+        application/json:
+          schema:
+            $ref: '#/components/schemas/Error'
+    Unauthorized:
+      description: Missing or invalid token
+      content:
+        application/json:
+          schema:
+            $ref: '#/components/schemas/Error'
+    NotFound:
+      description: No such user
+      content:
+        application/json:
+          schema:
+            $ref: '#/components/schemas/Error'
+    Unavailable:
+      description: The storage can't be reached, try again later
+      content:
+        application/json:
+          schema:
+            $ref: '#/components/schemas/Error'
+`